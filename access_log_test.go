@@ -0,0 +1,130 @@
+package yar
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLogCommonFormat(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger := NewAccessLogger(&buf, CommonLogFormat)
+	logger.now = func() time.Time { return time.Date(2016, 10, 1, 14, 3, 54, 0, time.UTC) }
+	router := NewRouter()
+	router.Use(logger.Middleware)
+	router.Get("/hello/:user", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("Hello " + GetParam(r, "user")))
+	})
+	r1, _ := http.NewRequest("GET", "/hello/gordon", nil)
+	r1.RemoteAddr = "127.0.0.1:54321"
+	r2, _ := http.NewRequest("GET", "/missing", nil)
+	r2.RemoteAddr = "127.0.0.1:54321"
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), r1)
+	router.ServeHTTP(httptest.NewRecorder(), r2)
+
+	// Assert
+	assert.Equal(t, `127.0.0.1 - - [01/Oct/2016:14:03:54 +0000] "GET /hello/:user HTTP/1.1" 201 12
+127.0.0.1 - - [01/Oct/2016:14:03:54 +0000] "GET - HTTP/1.1" 404 10
+`, buf.String())
+}
+
+func TestAccessLogCombinedFormat(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger := NewAccessLogger(&buf, CombinedLogFormat)
+	logger.now = func() time.Time { return time.Date(2016, 10, 1, 14, 3, 54, 0, time.UTC) }
+	router := NewRouter()
+	router.Use(logger.Middleware)
+	router.Get("/hello/:user", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("Hello " + GetParam(r, "user")))
+	})
+	r, _ := http.NewRequest("GET", "/hello/gordon", nil)
+	r.RemoteAddr = "127.0.0.1:54321"
+	r.Header.Set("User-Agent", "curl/7.50")
+	r.SetBasicAuth("gordon", "secret")
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	// Assert
+	assert.Equal(t, `127.0.0.1 - gordon [01/Oct/2016:14:03:54 +0000] "GET /hello/:user HTTP/1.1" 201 12 "" "curl/7.50"
+`, buf.String())
+}
+
+func TestAccessLogLatency(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	router := NewRouter()
+	router.Use((&AccessLogger{Out: &buf, Format: CombinedLogFormat, Latency: true}).Middleware) // Not created with NewAccessLogger
+	router.Get("/hello/:user", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("Hello " + GetParam(r, "user")))
+	})
+	r, _ := http.NewRequest("GET", "/hello/gordon", nil)
+	r.Header.Set("User-Agent", "curl/7.50")
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	// Assert
+	line := buf.String()
+	assert.Contains(t, line, `"GET /hello/:user HTTP/1.1" 201 12 "" "curl/7.50" `)
+	assert.True(t, strings.HasSuffix(line, "s\n"), line)
+}
+
+func TestAccessLogJSONFormat(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger := NewAccessLogger(&buf, JSONLogFormat)
+	clock := time.Date(2016, 10, 1, 14, 3, 54, 0, time.UTC)
+	logger.now = func() time.Time {
+		clock = clock.Add(1500 * time.Microsecond)
+		return clock
+	}
+	router := NewRouter()
+	router.Use(logger.Middleware)
+	router.Get("/hello/:user", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("Hello " + GetParam(r, "user")))
+	}).Named("hello")
+	r, _ := http.NewRequest("GET", "/hello/gordon", nil)
+	r.RemoteAddr = "127.0.0.1:54321"
+	r.Header.Set("User-Agent", "curl/7.50")
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	// Assert
+	assert.Equal(t, `{"time":"2016-10-01T14:03:54.0015Z","remote_addr":"127.0.0.1","method":"GET","pattern":"/hello/:user","route":"hello","proto":"HTTP/1.1","status":201,"bytes":12,"latency_ms":1.5,"user_agent":"curl/7.50"}
+`, buf.String())
+}
+
+func TestAccessLogPanic(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	router := NewRouter()
+	router.PanicHandler = DefaultPanicHandler
+	router.Use(NewAccessLogger(&buf, CommonLogFormat).Middleware)
+	router.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	r, _ := http.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, buf.String(), `"GET /panic HTTP/1.1" 500 -`)
+}
@@ -0,0 +1,346 @@
+package yar
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sources Bind reads values from, in the order they are applied, so a path parameter wins over a query parameter
+var bindSources = []string{"form", "query", "header", "path"}
+
+var bindTags = []string{"form", "query", "header", "path", "json"}
+
+// FieldError describes a struct field that could not be bound or is not valid
+type FieldError struct {
+	Field   string `json:"field"`          // Go field path, e.g. 'Page' or 'Filter.From'
+	Source  string `json:"source"`         // path, query, header, form or json
+	Name    string `json:"name,omitempty"` // Name in the source, e.g. the query parameter
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("%s: %s", e.Source, e.Message)
+	}
+	return fmt.Sprintf("%s '%s': %s", e.Source, e.Name, e.Message)
+}
+
+// BindError lists every field Bind could not fill
+type BindError struct {
+	Fields []FieldError
+}
+
+func (e *BindError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return "bind: " + strings.Join(msgs, "; ")
+}
+
+// Bind fills the struct dst points to from the request, by the fields' tags:
+//
+//	type UpdateUser struct {
+//	    ID      int       `path:"user_id"`
+//	    Notify  bool      `query:"notify"`
+//	    Tenant  string    `header:"X-Tenant"`
+//	    Name    string    `json:"name"` // From a JSON body
+//	    Avatar  string    `form:"avatar"` // From a url-encoded or multipart form body
+//	    Tags    []string  `query:"tag"` // Repeated values
+//	    Since   time.Time `query:"since"` // RFC 3339
+//	}
+//
+// Values are converted to strings, bools, ints, uints, floats, time.Duration, encoding.TextUnmarshaler
+// (e.g. time.Time), pointers and slices of those. Missing and empty values leave fields as they are, so defaults
+// can be set beforehand. Only fields tagged with 'json' are set from a JSON body. Nested structs are walked
+// for tags, prefixing field paths with their name, and so are exported embedded struct pointers, allocated when needed. Every field that fails is listed in the returned *BindError.
+func Bind(r *http.Request, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("bind: destination must be a non-nil pointer to a struct")
+	}
+	v = v.Elem()
+	fields := cachedBindFields(v.Type())
+	bindErr := &BindError{}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	hasBody := r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+	switch {
+	case hasBody && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")):
+		bindJSON(r, v, fields, bindErr)
+	case hasBody && mediaType == "multipart/form-data":
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			bindErr.Fields = append(bindErr.Fields, FieldError{Source: "form", Message: err.Error()})
+		}
+	case hasBody && mediaType == "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err != nil {
+			bindErr.Fields = append(bindErr.Fields, FieldError{Source: "form", Message: err.Error()})
+		}
+	}
+
+	query := r.URL.Query()
+	for _, field := range fields {
+		for _, source := range bindSources {
+			name := field.tags[source]
+			if name == "" {
+				continue
+			}
+			var values []string
+			switch source {
+			case "form":
+				values = r.PostForm[name]
+			case "query":
+				values = query[name]
+			case "header":
+				values = r.Header.Values(name)
+			case "path":
+				if value := GetParam(r, name); value != "" {
+					values = []string{value}
+				}
+			}
+			if len(values) == 0 || (len(values) == 1 && values[0] == "" && field.typ.Kind() != reflect.String) {
+				continue
+			}
+			if err := setBindValue(bindFieldValue(v, field.index), values); err != nil {
+				bindErr.Fields = append(bindErr.Fields, FieldError{Field: field.path, Source: source, Name: name, Message: err.Error()})
+			}
+		}
+	}
+
+	if len(bindErr.Fields) > 0 {
+		return bindErr
+	}
+	return nil
+}
+
+// Decodes the body into a new value, copying over only the fields tagged with 'json'
+func bindJSON(r *http.Request, v reflect.Value, fields []bindField, bindErr *BindError) {
+	decoded := reflect.New(v.Type())
+	if err := json.NewDecoder(r.Body).Decode(decoded.Interface()); err != nil {
+		fieldErr := FieldError{Source: "json", Message: err.Error()}
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			fieldErr.Field = jsonFieldPath(v.Type(), typeErr.Field)
+			fieldErr.Name = typeErr.Field[strings.LastIndex(typeErr.Field, ".")+1:]
+		}
+		if typeErr != nil {
+			fieldErr.Message = fmt.Sprintf("cannot use %s as %s", typeErr.Value, typeErr.Type)
+		}
+		bindErr.Fields = append(bindErr.Fields, fieldErr)
+		return
+	}
+	for _, field := range fields {
+		if field.tags["json"] == "" {
+			continue
+		}
+		if value, err := decoded.Elem().FieldByIndexErr(field.index); err == nil { // Fails on nil embedded pointers
+			bindFieldValue(v, field.index).Set(value)
+		}
+	}
+}
+
+// Converts the JSON path of a decoding error, e.g. 'address.city', to the Go field path, e.g. 'Address.City'
+func jsonFieldPath(t reflect.Type, jsonPath string) string {
+	names := []string{}
+	for _, key := range strings.Split(jsonPath, ".") {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Map:
+			names, t = append(names, key), t.Elem()
+		case reflect.Struct:
+			sf, ok := jsonStructField(t, key)
+			if !ok {
+				return jsonPath
+			}
+			names, t = append(names, sf.Name), sf.Type
+		default:
+			return jsonPath
+		}
+	}
+	return strings.Join(names, ".")
+}
+
+// Finds the field a JSON object key decodes into, like encoding/json does: by the name in its json tag or its own
+// name, ignoring case, looking into embedded structs
+func jsonStructField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" || (!sf.IsExported() && !sf.Anonymous) {
+			continue
+		}
+		if embedded := sf.Type; sf.Anonymous && name == "" {
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if found, ok := jsonStructField(embedded, key); ok {
+					return found, true
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if strings.EqualFold(name, key) {
+			return sf, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// Like v.FieldByIndex, but allocating nil embedded struct pointers on the way
+func bindFieldValue(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+type bindField struct {
+	index []int
+	path  string
+	typ   reflect.Type
+	tags  map[string]string // Name by source, including json
+}
+
+var bindFieldCache sync.Map // reflect.Type to []bindField
+
+func cachedBindFields(t reflect.Type) []bindField {
+	if fields, ok := bindFieldCache.Load(t); ok {
+		return fields.([]bindField)
+	}
+	fields := collectBindFields(t, nil, "")
+	bindFieldCache.Store(t, fields)
+	return fields
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func collectBindFields(t reflect.Type, index []int, prefix string) []bindField {
+	fields := []bindField{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() && !(sf.Anonymous && sf.Type.Kind() == reflect.Struct) { // Embedded structs may be unexported
+			continue
+		}
+		fieldIndex := append(index[:len(index):len(index)], i)
+		path := prefix + sf.Name
+		tags := map[string]string{}
+		for _, source := range bindTags {
+			if name, _, _ := strings.Cut(sf.Tag.Get(source), ","); name != "" && name != "-" {
+				tags[source] = name
+			}
+		}
+		if len(tags) > 0 && sf.IsExported() {
+			fields = append(fields, bindField{index: fieldIndex, path: path, typ: sf.Type, tags: tags})
+			continue
+		}
+		structType := sf.Type
+		if sf.Anonymous && sf.IsExported() && structType.Kind() == reflect.Pointer { // Allocated when a value is bound
+			structType = structType.Elem()
+		}
+		isStruct := structType.Kind() == reflect.Struct && !reflect.PointerTo(structType).Implements(textUnmarshalerType)
+		if isStruct {
+			if !sf.Anonymous {
+				path += "."
+			} else {
+				path = prefix // Embedded fields are promoted
+			}
+			fields = append(fields, collectBindFields(structType, fieldIndex, path)...)
+		}
+	}
+	return fields
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setBindValue(v reflect.Value, values []string) error {
+	isText := reflect.PointerTo(v.Type()).Implements(textUnmarshalerType)
+	if v.Kind() == reflect.Slice && !isText {
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setBindScalar(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return setBindScalar(v, values[0])
+}
+
+func setBindScalar(v reflect.Value, value string) error {
+	if v.Kind() == reflect.Pointer {
+		elem := reflect.New(v.Type().Elem())
+		if err := setBindScalar(elem.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("invalid value '%s': %v", value, err)
+		}
+		return nil
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration '%s'", value)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean '%s'", value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer '%s'", value)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer '%s'", value)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number '%s'", value)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
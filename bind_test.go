@@ -0,0 +1,228 @@
+package yar
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bindLevel int
+
+func (l *bindLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+type bindPage struct {
+	Page    int `query:"page"`
+	PerPage int `query:"per_page"`
+}
+
+type bindRequest struct {
+	bindPage
+	ID       int64         `path:"id"`
+	Verbose  bool          `query:"verbose"`
+	Tags     []string      `query:"tag"`
+	IDs      []uint        `query:"ids"`
+	Ratio    float64       `query:"ratio"`
+	Since    time.Time     `query:"since"`
+	Timeout  time.Duration `query:"timeout"`
+	Level    bindLevel     `query:"level"`
+	Limit    *int          `query:"limit"`
+	Tenant   string        `header:"X-Tenant"`
+	Name     string        `json:"name"`
+	Email    string        `json:"email,omitempty"`
+	Untagged string
+	Filter   struct {
+		From string `query:"from"`
+	}
+}
+
+func TestBind(t *testing.T) {
+	// Arrange
+	var dst bindRequest
+	var err error
+	router := NewRouter()
+	router.AddHandle("*", "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		dst = bindRequest{bindPage: bindPage{PerPage: 20}}
+		err = Bind(r, &dst)
+	})
+	query := "page=2&verbose=true&tag=a&tag=b&ids=1&ids=2&ratio=0.5&since=2020-01-02T03:04:05Z&timeout=1m&level=high&limit=7&from=x&per_page="
+	body := `{"name": "Ann", "email": "ann@example.com", "Untagged": "ignored", "ID": 99}`
+	r, _ := http.NewRequest("POST", "/users/42?"+query, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	r.Header.Set("X-Tenant", "acme")
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	// Assert
+	assert.Nil(t, err)
+	limit := 7
+	expected := bindRequest{
+		bindPage: bindPage{Page: 2, PerPage: 20},
+		ID:       42,
+		Verbose:  true,
+		Tags:     []string{"a", "b"},
+		IDs:      []uint{1, 2},
+		Ratio:    0.5,
+		Since:    time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Timeout:  time.Minute,
+		Level:    2,
+		Limit:    &limit,
+		Tenant:   "acme",
+		Name:     "Ann",
+		Email:    "ann@example.com",
+	}
+	expected.Filter.From = "x"
+	assert.Equal(t, expected, dst)
+}
+
+func TestBindForms(t *testing.T) {
+	type upload struct {
+		Title string   `form:"title"`
+		Count int      `form:"count"`
+		Tags  []string `form:"tag"`
+	}
+	var multipartBody bytes.Buffer
+	mw := multipart.NewWriter(&multipartBody)
+	mw.WriteField("title", "Report")
+	mw.WriteField("count", "3")
+	mw.WriteField("tag", "x")
+	mw.Close()
+
+	tcs := []struct {
+		contentType string
+		body        string
+	}{
+		{"application/x-www-form-urlencoded", url.Values{"title": {"Report"}, "count": {"3"}, "tag": {"x"}}.Encode()},
+		{mw.FormDataContentType(), multipartBody.String()},
+	}
+
+	for _, tc := range tcs {
+		// Arrange
+		r, _ := http.NewRequest("POST", "/upload?title=ignored", strings.NewReader(tc.body))
+		r.Header.Set("Content-Type", tc.contentType)
+		var dst upload
+
+		// Act
+		err := Bind(r, &dst)
+
+		// Assert
+		assert.Nil(t, err, tc.contentType)
+		assert.Equal(t, upload{Title: "Report", Count: 3, Tags: []string{"x"}}, dst, tc.contentType)
+	}
+}
+
+func TestBindListsEveryFailingField(t *testing.T) {
+	// Arrange
+	var err error
+	router := NewRouter()
+	router.AddHandle("*", "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		err = Bind(r, &bindRequest{})
+	})
+	r, _ := http.NewRequest("GET", "/users/abc?page=x&verbose=maybe&ids=1&ids=-2&level=medium&timeout=soon", nil)
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	// Assert
+	bindErr, ok := err.(*BindError)
+	assert.True(t, ok)
+	assert.Equal(t, []FieldError{
+		{Field: "Page", Source: "query", Name: "page", Message: "invalid integer 'x'"},
+		{Field: "ID", Source: "path", Name: "id", Message: "invalid integer 'abc'"},
+		{Field: "Verbose", Source: "query", Name: "verbose", Message: "invalid boolean 'maybe'"},
+		{Field: "IDs", Source: "query", Name: "ids", Message: "invalid unsigned integer '-2'"},
+		{Field: "Timeout", Source: "query", Name: "timeout", Message: "invalid duration 'soon'"},
+		{Field: "Level", Source: "query", Name: "level", Message: "invalid value 'medium': unknown level"},
+	}, bindErr.Fields)
+}
+
+func TestBindInvalidJSON(t *testing.T) {
+	tcs := []struct {
+		body            string
+		expectedField   string
+		expectedName    string
+		expectedMessage string
+	}{
+		{`{"name": 5}`, "Name", "name", "cannot use number as string"},
+		{`{"name": `, "", "", "unexpected EOF"},
+	}
+
+	for _, tc := range tcs {
+		// Arrange
+		r, _ := http.NewRequest("POST", "/", strings.NewReader(tc.body))
+		r.Header.Set("Content-Type", "application/json")
+
+		// Act
+		err := Bind(r, &bindRequest{})
+
+		// Assert
+		bindErr, ok := err.(*BindError)
+		assert.True(t, ok, tc.body)
+		assert.Equal(t, []FieldError{{Source: "json", Field: tc.expectedField, Name: tc.expectedName, Message: tc.expectedMessage}}, bindErr.Fields, tc.body)
+	}
+}
+
+func TestBindInvalidNestedJSON(t *testing.T) {
+	// Arrange
+	var dst struct {
+		Address struct {
+			City string `json:"city"`
+		} `json:"address"`
+	}
+	r, _ := http.NewRequest("POST", "/", strings.NewReader(`{"address": {"city": true}}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	// Act
+	err := Bind(r, &dst)
+
+	// Assert
+	bindErr, ok := err.(*BindError)
+	assert.True(t, ok)
+	assert.Equal(t, []FieldError{{Source: "json", Field: "Address.City", Name: "city", Message: "cannot use bool as string"}}, bindErr.Fields)
+}
+
+func TestBindEmbeddedPointer(t *testing.T) {
+	// Arrange
+	type Paging bindPage
+	var dst struct {
+		*Paging
+		Name string `json:"name"`
+	}
+	r, _ := http.NewRequest("POST", "/?page=3", strings.NewReader(`{"name": "Ann"}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	// Act
+	err := Bind(r, &dst)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, &Paging{Page: 3}, dst.Paging)
+	assert.Equal(t, "Ann", dst.Name)
+}
+
+func TestBindRequiresStructPointer(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+	var s struct{}
+
+	assert.NotNil(t, Bind(r, s))
+	assert.NotNil(t, Bind(r, (*bindRequest)(nil)))
+	assert.Nil(t, Bind(r, &s))
+}
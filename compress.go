@@ -0,0 +1,297 @@
+package yar
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultSkipContentTypes are content types that are already compressed, see Compressor.SkipContentTypes
+var DefaultSkipContentTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"video/*", "audio/*", "font/woff", "font/woff2",
+	"application/zip", "application/gzip", "application/x-gzip", "application/x-bzip2", "application/zstd",
+	"application/x-7z-compressed", "application/x-rar-compressed", "application/pdf",
+}
+
+// Compressor is a middleware compressing responses with gzip or deflate, as negotiated with the Accept-Encoding
+// request header, for a route, a group or the whole router:
+//
+//	router.Use(yar.NewCompressor(gzip.DefaultCompression).Middleware)
+//
+// Responses to HEAD requests, without a body (204, 304), with a Content-Encoding or Content-Range, of a skipped
+// content type, or smaller than MinSize are sent as they are. Responses the handler flushes before reaching
+// MinSize are compressed, as their final size is unknown.
+type Compressor struct {
+	Level            int      // From flate.HuffmanOnly to flate.BestCompression
+	MinSize          int      // In bytes
+	SkipContentTypes []string // E.g. 'image/png' or 'video/*', matched against the Content-Type or the sniffed type
+
+	gzipPool  sync.Pool
+	flatePool sync.Pool
+}
+
+// NewCompressor creates a compressor with the given level, a MinSize of 1KB and the DefaultSkipContentTypes
+func NewCompressor(level int) *Compressor {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		panic("invalid compression level " + strconv.Itoa(level))
+	}
+	return &Compressor{Level: level, MinSize: 1024, SkipContentTypes: DefaultSkipContentTypes}
+}
+
+func (c *Compressor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		addVary(w.Header(), "Accept-Encoding")
+		encoding := negotiateEncoding(req.Header.Get("Accept-Encoding"))
+		if encoding == "" || req.Method == "HEAD" {
+			next.ServeHTTP(w, req)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, compressor: c, encoding: encoding, status: http.StatusOK}
+		next.ServeHTTP(cw.writer(), req)
+		cw.close() // Not deferred, a panicking handler's partial response must not be sent
+	})
+}
+
+// Adds a Vary header value, unless it is already listed, e.g. by another middleware
+func addVary(header http.Header, value string) {
+	for _, line := range header.Values("Vary") {
+		for _, listed := range strings.Split(line, ",") {
+			if listed = strings.TrimSpace(listed); listed == "*" || strings.EqualFold(listed, value) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", value)
+}
+
+// Picks gzip or deflate by their q-values in an Accept-Encoding header, preferring gzip, empty for neither
+func negotiateEncoding(acceptEncoding string) string {
+	q := parseAcceptEncoding(acceptEncoding)
+	best, bestQ := "", 0.0
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if value := q.value(encoding); value > bestQ {
+			best, bestQ = encoding, value
+		}
+	}
+	return best
+}
+
+// Content codings of an Accept-Encoding header, with their q-values
+type acceptEncodingQ map[string]float64
+
+func parseAcceptEncoding(acceptEncoding string) acceptEncodingQ {
+	q := acceptEncodingQ{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		value := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if name, v, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.ToLower(strings.TrimSpace(name)) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					value = parsed
+				} else {
+					value = 0
+				}
+			}
+		}
+		if coding = strings.ToLower(strings.TrimSpace(coding)); coding != "" {
+			q[coding] = value
+		}
+	}
+	return q
+}
+
+// Returns the encoding's q-value, falling back to '*', 0 if not acceptable
+func (q acceptEncodingQ) value(encoding string) float64 {
+	if value, ok := q[encoding]; ok {
+		return value
+	}
+	return q["*"]
+}
+
+func (c *Compressor) getWriter(encoding string, w io.Writer) io.WriteCloser {
+	if encoding == "gzip" {
+		if gz, ok := c.gzipPool.Get().(*gzip.Writer); ok {
+			gz.Reset(w)
+			return gz
+		}
+		gz, _ := gzip.NewWriterLevel(w, c.Level)
+		return gz
+	}
+	if fw, ok := c.flatePool.Get().(*flate.Writer); ok {
+		fw.Reset(w)
+		return fw
+	}
+	fw, _ := flate.NewWriter(w, c.Level)
+	return fw
+}
+
+func (c *Compressor) putWriter(cw io.WriteCloser) {
+	switch cw := cw.(type) {
+	case *gzip.Writer:
+		c.gzipPool.Put(cw)
+	case *flate.Writer:
+		c.flatePool.Put(cw)
+	}
+}
+
+// compressWriter buffers the start of the response until it knows whether to compress it
+type compressWriter struct {
+	http.ResponseWriter
+	compressor *Compressor
+	encoding   string
+
+	status      int
+	wroteHeader bool           // By the handler, only forwarded once decided
+	buf         []byte         // Until decided
+	decided     bool           // Header written, either compressing or passing through
+	encoder     io.WriteCloser // Compressing writer, nil when passing through
+	hijacked    bool
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if status < 200 { // Informational responses go out straight away
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if !cw.wroteHeader {
+		cw.status = status
+		cw.wroteHeader = true
+	}
+	if !cw.bodyAllowed() {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	cw.wroteHeader = true
+	if !cw.decided {
+		if len(cw.buf)+len(b) < cw.compressor.MinSize {
+			cw.buf = append(cw.buf, b...)
+			return len(b), nil
+		}
+		cw.decide(true)
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Unwrap allows http.ResponseController to reach the wrapped writer
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Each adds one of the optional interfaces, see writer
+type (
+	compressFlusher  struct{ *compressWriter }
+	compressHijacker struct{ *compressWriter }
+)
+
+// Flush sends what has been compressed so far
+func (cw compressFlusher) Flush() {
+	if !cw.decided {
+		cw.decide(true)
+	}
+	if fw, ok := cw.encoder.(interface{ Flush() error }); ok {
+		fw.Flush()
+	}
+	cw.ResponseWriter.(http.Flusher).Flush()
+}
+
+// Hijack hands the connection over as it is, what has been buffered is not sent
+func (cw compressHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := cw.ResponseWriter.(http.Hijacker).Hijack()
+	cw.hijacked = err == nil
+	return conn, rw, err
+}
+
+// writer returns cw implementing http.Flusher and http.Hijacker if the wrapped writer does
+func (cw *compressWriter) writer() http.ResponseWriter {
+	_, flusher := cw.ResponseWriter.(http.Flusher)
+	_, hijacker := cw.ResponseWriter.(http.Hijacker)
+	switch {
+	case flusher && hijacker:
+		return struct {
+			*compressWriter
+			compressFlusher
+			compressHijacker
+		}{cw, compressFlusher{cw}, compressHijacker{cw}}
+	case flusher:
+		return struct {
+			*compressWriter
+			compressFlusher
+		}{cw, compressFlusher{cw}}
+	case hijacker:
+		return struct {
+			*compressWriter
+			compressHijacker
+		}{cw, compressHijacker{cw}}
+	}
+	return cw
+}
+
+func (cw *compressWriter) bodyAllowed() bool {
+	return cw.status != http.StatusNoContent && cw.status != http.StatusNotModified
+}
+
+// Writes the header, setting up compression if it is wanted and the response qualifies
+func (cw *compressWriter) decide(wanted bool) {
+	cw.decided = true
+	header := cw.Header()
+	if wanted && cw.shouldCompress(header) {
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag) // No longer byte for byte the same
+		}
+		cw.encoder = cw.compressor.getWriter(cw.encoding, cw.ResponseWriter)
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if len(cw.buf) > 0 {
+		buf := cw.buf
+		cw.buf = nil
+		if cw.encoder != nil {
+			cw.encoder.Write(buf)
+		} else {
+			cw.ResponseWriter.Write(buf)
+		}
+	}
+}
+
+func (cw *compressWriter) shouldCompress(header http.Header) bool {
+	if !cw.bodyAllowed() || header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(cw.buf)
+		header.Set("Content-Type", contentType) // As net/http would, it can't sniff the compressed body
+	}
+	return !matchesContentType(cw.compressor.SkipContentTypes, contentType)
+}
+
+// Called once the handler returned, sending small responses uncompressed
+func (cw *compressWriter) close() {
+	if cw.hijacked {
+		return
+	}
+	if !cw.decided {
+		if !cw.wroteHeader {
+			return // Nothing written, e.g. hijacked or left to the server's defaults
+		}
+		cw.decide(false)
+	}
+	if cw.encoder != nil {
+		cw.encoder.Close()
+		cw.compressor.putWriter(cw.encoder)
+		cw.encoder = nil
+	}
+}
@@ -0,0 +1,227 @@
+package yar
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tcs := []struct {
+		acceptEncoding, expected string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"deflate, gzip", "gzip"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"GZIP;Q=0.8, deflate;q=0.9", "deflate"},
+		{"gzip;q=0, deflate;q=0", ""},
+		{"*", "gzip"},
+		{"*;q=0.1, gzip;q=0", "deflate"},
+		{"br, identity", ""},
+		{"gzip;q=bad", ""},
+	}
+
+	for _, tc := range tcs {
+		assert.Equal(t, tc.expected, negotiateEncoding(tc.acceptEncoding), tc.acceptEncoding)
+	}
+}
+
+var largeBody = strings.Repeat("compress me ", 200)
+
+func TestCompressorGzip(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Use(NewCompressor(gzip.DefaultCompression).Middleware)
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "2400")
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(largeBody[:1000]))
+		w.Write([]byte(largeBody[1000:]))
+	})
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip, deflate")
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Equal(t, "", w.Header().Get("Content-Length"))
+	assert.Equal(t, `W/"v1"`, w.Header().Get("ETag"))
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	reader, err := gzip.NewReader(w.Body)
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(reader)
+	assert.Equal(t, largeBody, string(body))
+}
+
+func TestCompressorDeflate(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Use(NewCompressor(gzip.DefaultCompression).Middleware)
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(largeBody))
+	})
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", "deflate")
+	w1, w2 := httptest.NewRecorder(), httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w1, r)
+	router.ServeHTTP(w2, r) // Reuses a pooled writer
+
+	// Assert
+	for _, w := range []*httptest.ResponseRecorder{w1, w2} {
+		assert.Equal(t, "deflate", w.Header().Get("Content-Encoding"))
+		body, _ := io.ReadAll(flate.NewReader(w.Body))
+		assert.Equal(t, largeBody, string(body))
+	}
+}
+
+func TestCompressorPassesThrough(t *testing.T) {
+	tcs := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		handler        func(http.ResponseWriter, *http.Request)
+		expectedCode   int
+		expectedBody   string
+	}{
+		{"small", "GET", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("small"))
+		}, http.StatusOK, "small"},
+		{"not accepted", "GET", "br", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(largeBody))
+		}, http.StatusOK, largeBody},
+		{"head", "HEAD", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", "2400")
+		}, http.StatusOK, ""},
+		{"no content", "GET", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, http.StatusNoContent, ""},
+		{"not modified", "GET", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotModified)
+		}, http.StatusNotModified, ""},
+		{"already compressed type", "GET", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(largeBody))
+		}, http.StatusOK, largeBody},
+		{"already encoded", "GET", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "br")
+			w.Write([]byte(largeBody))
+		}, http.StatusOK, largeBody},
+		{"partial content", "GET", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Range", "bytes 0-2399/5000")
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write([]byte(largeBody))
+		}, http.StatusPartialContent, largeBody},
+	}
+
+	for _, tc := range tcs {
+		// Arrange
+		router := NewRouter()
+		router.AddHandle("*", "/", tc.handler).Use(NewCompressor(gzip.DefaultCompression).Middleware)
+		r, _ := http.NewRequest(tc.method, "/", nil)
+		r.Header.Set("Accept-Encoding", tc.acceptEncoding)
+		w := httptest.NewRecorder()
+
+		// Act
+		router.ServeHTTP(w, r)
+
+		// Assert
+		assert.Equal(t, tc.expectedCode, w.Code, tc.name)
+		assert.NotEqual(t, "gzip", w.Header().Get("Content-Encoding"), tc.name)
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"), tc.name)
+		assert.Equal(t, tc.expectedBody, w.Body.String(), tc.name)
+	}
+}
+
+func TestCompressorStreaming(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Use(NewCompressor(gzip.DefaultCompression).Middleware)
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("data: 2\n\n"))
+	})
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	reader, _ := gzip.NewReader(w.Body)
+	body, _ := io.ReadAll(reader)
+	assert.Equal(t, "data: 1\n\ndata: 2\n\n", string(body))
+}
+
+func TestCompressorDoesNotRepeatVary(t *testing.T) {
+	// Arrange
+	compressor := NewCompressor(gzip.DefaultCompression)
+	router := NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Vary", "Origin, accept-encoding")
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.Use(compressor.Middleware, compressor.Middleware)
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, []string{"Origin, accept-encoding"}, w.Header().Values("Vary"))
+}
+
+func TestCompressorPassesInterfacesThrough(t *testing.T) {
+	// Arrange
+	var flusher, hijacker bool
+	router := NewRouter()
+	router.Use(NewCompressor(gzip.DefaultCompression).Middleware)
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		_, flusher = w.(http.Flusher)
+		_, hijacker = w.(http.Hijacker)
+		if hijacker {
+			w.(http.Hijacker).Hijack()
+		}
+	})
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	recorder := httptest.NewRecorder()
+	underlying := &hijackableRecorder{ResponseRecorder: recorder}
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), r)
+	plainFlusher, plainHijacker := flusher, hijacker
+	router.ServeHTTP(underlying, r)
+
+	// Assert
+	assert.True(t, plainFlusher)
+	assert.False(t, plainHijacker)
+	assert.True(t, flusher)
+	assert.True(t, hijacker)
+	assert.True(t, underlying.hijacked)
+	assert.Equal(t, "", recorder.Header().Get("Content-Encoding"))
+}
@@ -0,0 +1,290 @@
+package yar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RouteConfig describes routes declaratively, e.g. in a JSON or YAML file:
+//
+//	routes:
+//	  - method: GET
+//	    pattern: /users/:id
+//	    handler: getUser
+//	    name: user
+//	    middleware: [auth, audit]
+//	    meta: {scope: users.read}
+type RouteConfig struct {
+	Routes []RouteConfigEntry `json:"routes"`
+}
+
+type RouteConfigEntry struct {
+	Method     string                 `json:"method"` // AnyMethod ('*') handles all methods without their own handler
+	Host       string                 `json:"host,omitempty"`
+	Pattern    string                 `json:"pattern"`
+	Handler    string                 `json:"handler"`              // Name in HandlerRegistry.Handlers
+	Name       string                 `json:"name,omitempty"`       // Route name, must be the same for all methods of a route
+	Middleware []string               `json:"middleware,omitempty"` // Names in HandlerRegistry.Middlewares, the first one is the outermost
+	Meta       map[string]interface{} `json:"meta,omitempty"`       // Set as method metadata with string keys, route wide for AnyMethod
+}
+
+// HandlerRegistry binds the handler and middleware names used in a RouteConfig
+type HandlerRegistry struct {
+	Handlers    map[string]http.Handler
+	Middlewares map[string]Middleware
+}
+
+// RouteConfigError lists every problem found in a RouteConfig
+type RouteConfigError struct {
+	Errors []string
+}
+
+func (e *RouteConfigError) Error() string {
+	return "invalid route config: " + strings.Join(e.Errors, "; ")
+}
+
+// ParseRouteConfig decodes a RouteConfig in the given format, "json" or "yaml"
+func ParseRouteConfig(data []byte, format string) (*RouteConfig, error) {
+	config := &RouteConfig{}
+	var err error
+	switch format {
+	case "json":
+		err = json.Unmarshal(data, config)
+	case "yaml":
+		err = unmarshalYaml(data, config)
+	default:
+		return nil, fmt.Errorf("unknown route config format '%s', expected one of: json, yaml", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode route config: %v", err)
+	}
+	return config, nil
+}
+
+// LoadRouteConfig reads a RouteConfig file, its format is chosen by the extension: .json, .yaml or .yml
+func LoadRouteConfig(path string) (*RouteConfig, error) {
+	format, err := routeConfigFormat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRouteConfig(data, format)
+}
+
+func routeConfigFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json", nil
+	case ".yaml", ".yml":
+		return "yaml", nil
+	}
+	return "", fmt.Errorf("cannot tell route config format from extension, path=%s", path)
+}
+
+// ApplyRouteConfig replaces the routes of the previously applied config with the configured ones, adding them to the
+// routes registered in code (e.g. by ServeFiles or RegisterOpenAPI). Configured methods cannot replace the ones of
+// code routes. The new routes are built aside, from copies of the code routes, and swapped in atomically, so requests
+// in flight are not affected. If the config is invalid a *RouteConfigError is returned and the current routes are kept.
+// Middlewares added with Use are kept either way.
+func (r *Router) ApplyRouteConfig(config *RouteConfig, registry *HandlerRegistry) error {
+	scratch := NewRouter()
+	for _, route := range r.table.Load().routes() {
+		if route := route.withoutConfig(); route != nil {
+			scratch.table.Load().trieFor(route.Host).AddRoute(route)
+		}
+	}
+	configErr := &RouteConfigError{}
+	for i, entry := range config.Routes {
+		if err := scratch.addConfigEntry(entry, registry); err != nil {
+			configErr.Errors = append(configErr.Errors, fmt.Sprintf("routes[%d] (%s %s): %v", i, entry.Method, entry.Pattern, err))
+		}
+	}
+	if len(configErr.Errors) > 0 {
+		return configErr
+	}
+	r.table.Store(scratch.table.Load())
+	return nil
+}
+
+func (r *Router) addConfigEntry(entry RouteConfigEntry, registry *HandlerRegistry) (err error) {
+	if entry.Method == "" {
+		return fmt.Errorf("method is required")
+	}
+	if _, err := ParsePattern(entry.Pattern); err != nil {
+		return err
+	}
+	handler := registry.Handlers[entry.Handler]
+	if handler == nil {
+		return fmt.Errorf("unknown handler '%s'", entry.Handler)
+	}
+	for i := len(entry.Middleware) - 1; i >= 0; i-- {
+		middleware := registry.Middlewares[entry.Middleware[i]]
+		if middleware == nil {
+			return fmt.Errorf("unknown middleware '%s'", entry.Middleware[i])
+		}
+		handler = middleware(handler)
+	}
+
+	defer func() { // Collisions with other routes panic, as they do when registering in code
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%v", p)
+		}
+	}()
+	route := r.addHandler(entry.Host, entry.Method, entry.Pattern, handler, false)
+	if route.config == nil {
+		route.config = &routeConfigState{}
+	}
+	route.config.methods = append(route.config.methods, entry.Method)
+	if entry.Name != "" {
+		if route.Name != "" && route.Name != entry.Name {
+			return fmt.Errorf("route is already named '%s'", route.Name)
+		}
+		route.config.name = route.config.name || route.Name == ""
+		route.Named(entry.Name)
+	}
+	for key, value := range entry.Meta {
+		if entry.Method == AnyMethod {
+			previous, existed := route.Meta[key]
+			route.config.meta = append(route.config.meta, routeConfigMeta{key, previous, existed})
+			route.WithMeta(key, value)
+		} else {
+			route.WithMethodMeta(entry.Method, key, value)
+		}
+	}
+	return nil
+}
+
+// What a config added to a route, removed before applying the next one
+type routeConfigState struct {
+	methods []string
+	name    bool
+	meta    []routeConfigMeta // Route wide metadata, in the order it was set
+}
+
+type routeConfigMeta struct {
+	key      string
+	previous interface{}
+	existed  bool
+}
+
+// Returns a copy of the route without what ApplyRouteConfig added to it, nil if that leaves no method handlers
+func (rt *Route) withoutConfig() *Route {
+	clone := *rt
+	clone.config = nil
+	clone.Handlers = make(map[string]http.Handler, len(rt.Handlers))
+	for method, handler := range rt.Handlers {
+		clone.Handlers[method] = handler
+	}
+	clone.Meta = make(Metadata, len(rt.Meta))
+	for key, value := range rt.Meta {
+		clone.Meta[key] = value
+	}
+	clone.MethodMeta = make(map[string]Metadata, len(rt.MethodMeta))
+	for method, meta := range rt.MethodMeta {
+		clone.MethodMeta[method] = make(Metadata, len(meta))
+		for key, value := range meta {
+			clone.MethodMeta[method][key] = value
+		}
+	}
+	if rt.config != nil {
+		for _, method := range rt.config.methods {
+			delete(clone.Handlers, method)
+			delete(clone.MethodMeta, method)
+		}
+		for i := len(rt.config.meta) - 1; i >= 0; i-- {
+			if meta := rt.config.meta[i]; meta.existed {
+				clone.Meta[meta.key] = meta.previous
+			} else {
+				delete(clone.Meta, meta.key)
+			}
+		}
+		if rt.config.name {
+			clone.Name = ""
+		}
+		if clone.serveMux && clone.Handlers["HEAD"] == nil && clone.Handlers["GET"] != nil { // Configured HEAD replaced it
+			clone.Handlers["HEAD"] = clone.Handlers["GET"]
+			clone.implicitHead = true
+		}
+	}
+	if len(clone.Handlers) == 0 {
+		return nil
+	}
+	clone.middlewares = append([]Middleware(nil), rt.middlewares...)
+	clone.chains = nil
+	clone.buildChains()
+	return &clone
+}
+
+// RouteConfigLoader applies a route config file to a router, on demand with Reload or whenever the file changes with Watch
+type RouteConfigLoader struct {
+	Router   *Router
+	Path     string
+	Registry *HandlerRegistry
+	OnError  func(err error) // Called by Watch when a reload fails, the router keeps its current routes
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+}
+
+// Reload reads and applies the config file, keeping the current routes if it is invalid
+func (l *RouteConfigLoader) Reload() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	format, err := routeConfigFormat(l.Path)
+	if err != nil {
+		return err
+	}
+	info, statErr := os.Stat(l.Path) // Before reading, so a change made while reading is picked up by the next check
+	data, err := os.ReadFile(l.Path)
+	if err != nil {
+		return err // Not recorded, so Watch tries again
+	}
+	if statErr == nil {
+		l.modTime, l.size = info.ModTime(), info.Size()
+	}
+	config, err := ParseRouteConfig(data, format)
+	if err != nil {
+		return err
+	}
+	return l.Router.ApplyRouteConfig(config, l.Registry)
+}
+
+// Watch checks the file's modification time and size every interval, reloading it when they change, until ctx is done
+func (l *RouteConfigLoader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !l.changed() {
+			continue
+		}
+		if err := l.Reload(); err != nil && l.OnError != nil {
+			l.OnError(err)
+		}
+	}
+}
+
+func (l *RouteConfigLoader) changed() bool {
+	info, err := os.Stat(l.Path)
+	if err != nil {
+		return false // E.g. replaced by an editor, picked up once it exists again
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return !info.ModTime().Equal(l.modTime) || info.Size() != l.size
+}
@@ -0,0 +1,233 @@
+package yar
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRegistry() *HandlerRegistry {
+	respond := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body + GetParam(r, "id")))
+		})
+	}
+	return &HandlerRegistry{
+		Handlers: map[string]http.Handler{
+			"getUser":  respond("user "),
+			"postUser": respond("created"),
+			"health":   respond("ok"),
+		},
+		Middlewares: map[string]Middleware{
+			"tag": func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("X-Tag", "tagged")
+					next.ServeHTTP(w, r)
+				})
+			},
+		},
+	}
+}
+
+const testRouteConfigYaml = `
+routes:
+  - method: GET
+    pattern: /users/:id
+    handler: getUser
+    name: user
+    middleware: [tag]
+    meta:
+      scope: users.read
+  - method: POST
+    pattern: /users/:id
+    handler: postUser
+`
+
+func TestParseRouteConfigFormats(t *testing.T) {
+	// Arrange
+	json := `{"routes": [{"method": "GET", "pattern": "/users/:id", "handler": "getUser", "name": "user",
+		"middleware": ["tag"], "meta": {"scope": "users.read"}}, {"method": "POST", "pattern": "/users/:id", "handler": "postUser"}]}`
+
+	// Act
+	fromJson, err1 := ParseRouteConfig([]byte(json), "json")
+	fromYaml, err2 := ParseRouteConfig([]byte(testRouteConfigYaml), "yaml")
+	_, err3 := ParseRouteConfig([]byte(json), "toml")
+
+	// Assert
+	assert.Nil(t, err1)
+	assert.Nil(t, err2)
+	assert.NotNil(t, err3)
+	assert.Equal(t, fromJson, fromYaml)
+	assert.Equal(t, RouteConfigEntry{Method: "GET", Pattern: "/users/:id", Handler: "getUser", Name: "user",
+		Middleware: []string{"tag"}, Meta: map[string]interface{}{"scope": "users.read"}}, fromYaml.Routes[0])
+}
+
+func TestApplyRouteConfig(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/old", func(w http.ResponseWriter, r *http.Request) {})
+	config, _ := ParseRouteConfig([]byte(testRouteConfigYaml), "yaml")
+	rGet, _ := http.NewRequest("GET", "/users/7", nil)
+	rPost, _ := http.NewRequest("POST", "/users/7", nil)
+	rOld, _ := http.NewRequest("GET", "/old", nil)
+	wGet, wPost, wOld := httptest.NewRecorder(), httptest.NewRecorder(), httptest.NewRecorder()
+
+	// Act
+	err := router.ApplyRouteConfig(config, testRegistry())
+	router.ServeHTTP(wGet, rGet)
+	router.ServeHTTP(wPost, rPost)
+	router.ServeHTTP(wOld, rOld)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "user 7", wGet.Body.String())
+	assert.Equal(t, "tagged", wGet.Header().Get("X-Tag"))
+	assert.Equal(t, "created7", wPost.Body.String())
+	assert.Equal(t, http.StatusOK, wOld.Code) // Registered in code
+
+	routes := router.Routes()
+	assert.Equal(t, 2, len(routes))
+	assert.Equal(t, "user", routes[1].Name)
+	assert.Equal(t, "users.read", routes[1].Route.MetaValue("GET", "scope"))
+	assert.Nil(t, routes[1].Route.MetaValue("POST", "scope"))
+}
+
+func TestApplyRouteConfigReplacesPreviousConfig(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("code")) }).WithMeta("owner", "code")
+	first := &RouteConfig{Routes: []RouteConfigEntry{
+		{Method: "POST", Pattern: "/users/:id", Handler: "postUser", Name: "user", Meta: map[string]interface{}{"scope": "users.write"}},
+		{Method: "*", Pattern: "/users/:id", Handler: "health", Meta: map[string]interface{}{"owner": "config"}},
+		{Method: "GET", Pattern: "/health", Handler: "health"},
+	}}
+	second := &RouteConfig{Routes: []RouteConfigEntry{
+		{Method: "DELETE", Pattern: "/users/:id", Handler: "health", Name: "account"},
+	}}
+	conflicting := &RouteConfig{Routes: []RouteConfigEntry{
+		{Method: "GET", Pattern: "/users/:id", Handler: "health"},
+	}}
+	rGet, _ := http.NewRequest("GET", "/users/7", nil)
+	rPost, _ := http.NewRequest("POST", "/users/7", nil)
+	rDelete, _ := http.NewRequest("DELETE", "/users/7", nil)
+	rHealth, _ := http.NewRequest("GET", "/health", nil)
+	wCreated, wGet, wPost := httptest.NewRecorder(), httptest.NewRecorder(), httptest.NewRecorder()
+	wDelete, wHealth := httptest.NewRecorder(), httptest.NewRecorder()
+
+	// Act
+	err1 := router.ApplyRouteConfig(first, testRegistry())
+	router.ServeHTTP(wCreated, rPost)
+	err2 := router.ApplyRouteConfig(second, testRegistry())
+	err3 := router.ApplyRouteConfig(conflicting, testRegistry())
+	router.ServeHTTP(wGet, rGet)
+	router.ServeHTTP(wPost, rPost)
+	router.ServeHTTP(wDelete, rDelete)
+	router.ServeHTTP(wHealth, rHealth)
+
+	// Assert
+	assert.Nil(t, err1)
+	assert.Nil(t, err2)
+	assert.NotNil(t, err3)
+	assert.Equal(t, "created7", wCreated.Body.String())
+	assert.Equal(t, "code", wGet.Body.String())
+	assert.Equal(t, http.StatusMethodNotAllowed, wPost.Code)
+	assert.Equal(t, http.StatusOK, wDelete.Code)
+	assert.Equal(t, http.StatusNotFound, wHealth.Code)
+	routes := router.Routes()
+	assert.Equal(t, 1, len(routes))
+	assert.Equal(t, "account", routes[0].Name)
+	assert.Equal(t, []string{"DELETE", "GET"}, routes[0].Methods)
+	assert.Equal(t, "code", routes[0].Route.MetaValue("GET", "owner"))
+	assert.Nil(t, routes[0].Route.MetaValue("POST", "scope"))
+}
+
+func TestApplyRouteConfigErrorsKeepRoutes(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/old", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("old")) })
+	config := &RouteConfig{Routes: []RouteConfigEntry{
+		{Method: "GET", Pattern: "/ok", Handler: "health"},
+		{Method: "GET", Pattern: "/a", Handler: "unknown"},
+		{Method: "GET", Pattern: "/b", Handler: "health", Middleware: []string{"unknown"}},
+		{Method: "GET", Pattern: "no-slash", Handler: "health"},
+		{Pattern: "/c", Handler: "health"},
+		{Method: "GET", Pattern: "/ok", Handler: "health"},
+	}}
+	rOld, _ := http.NewRequest("GET", "/old", nil)
+	rOk, _ := http.NewRequest("GET", "/ok", nil)
+	wOld, wOk := httptest.NewRecorder(), httptest.NewRecorder()
+
+	// Act
+	err := router.ApplyRouteConfig(config, testRegistry())
+	router.ServeHTTP(wOld, rOld)
+	router.ServeHTTP(wOk, rOk)
+
+	// Assert
+	configErr, ok := err.(*RouteConfigError)
+	assert.True(t, ok)
+	assert.Equal(t, 5, len(configErr.Errors))
+	assert.Equal(t, "old", wOld.Body.String())
+	assert.Equal(t, http.StatusNotFound, wOk.Code)
+}
+
+func TestRouteConfigLoaderReload(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+	os.WriteFile(path, []byte(testRouteConfigYaml), 0644)
+	loader := &RouteConfigLoader{Router: NewRouter(), Path: path, Registry: testRegistry()}
+	r, _ := http.NewRequest("GET", "/users/1", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	err1 := loader.Reload()
+	os.WriteFile(path, []byte("routes:\n  - {method: GET, pattern: /users/:id, handler: missing}\n"), 0644)
+	err2 := loader.Reload()
+	loader.Router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Nil(t, err1)
+	assert.NotNil(t, err2)
+	assert.Equal(t, "user 1", w.Body.String())
+}
+
+func TestRouteConfigLoaderWatch(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "routes.json")
+	os.WriteFile(path, []byte(`{"routes": [{"method": "GET", "pattern": "/health", "handler": "health"}]}`), 0644)
+	errs := make(chan error, 10)
+	loader := &RouteConfigLoader{Router: NewRouter(), Path: path, Registry: testRegistry(),
+		OnError: func(err error) { errs <- err }}
+	loader.Reload()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go loader.Watch(ctx, time.Millisecond)
+	r, _ := http.NewRequest("GET", "/users/2", nil)
+	reloaded, kept := httptest.NewRecorder(), httptest.NewRecorder()
+
+	// Act
+	os.WriteFile(path, []byte(`{"routes": [{"method": "GET", "pattern": "/users/:id", "handler": "getUser"}]}`), 0644)
+
+	// Assert
+	deadline := time.Now().Add(time.Second)
+	for loader.Router.Routes()[0].Pattern != "/users/:id" && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	loader.Router.ServeHTTP(reloaded, r)
+	assert.Equal(t, "user 2", reloaded.Body.String())
+
+	os.WriteFile(path, []byte(`{"routes": [`), 0644)
+	select {
+	case err := <-errs:
+		assert.NotNil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("expected a reload error")
+	}
+	loader.Router.ServeHTTP(kept, r)
+	assert.Equal(t, "user 2", kept.Body.String())
+}
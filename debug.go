@@ -0,0 +1,144 @@
+package yar
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DebugHandler renders a router's route table (HTML or JSON) and its route trie (Graphviz DOT).
+// The format is picked with the 'format' query parameter: 'html' (default), 'json' or 'dot'.
+//
+// Mount it like any other handler, preferably behind authentication:
+//
+//	router.AddHandler("GET", "/debug/routes", yar.NewDebugHandler(router))
+type DebugHandler struct {
+	router *Router
+}
+
+func NewDebugHandler(router *Router) *DebugHandler {
+	return &DebugHandler{router: router}
+}
+
+type debugRoute struct {
+	Host    string            `json:"host,omitempty"`
+	Pattern string            `json:"pattern"`
+	Methods []string          `json:"methods"`
+	Name    string            `json:"name,omitempty"`
+	Meta    map[string]string `json:"meta,omitempty"`
+}
+
+func (h *DebugHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.URL.Query().Get("format") {
+	case "json":
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(h.routes())
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		h.router.WriteDot(w)
+	case "", "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		debugTemplate.Execute(w, h.routes())
+	default:
+		http.Error(w, "unknown format, expected one of: html, json, dot", http.StatusBadRequest)
+	}
+}
+
+func (h *DebugHandler) routes() []debugRoute {
+	infos := h.router.Routes()
+	routes := make([]debugRoute, len(infos))
+	for i, info := range infos {
+		routes[i] = debugRoute{
+			Host:    info.Host,
+			Pattern: info.Pattern,
+			Methods: info.Methods,
+			Name:    info.Name,
+		}
+		if len(info.Meta) > 0 {
+			routes[i].Meta = make(map[string]string, len(info.Meta))
+			for k, v := range info.Meta {
+				routes[i].Meta[fmt.Sprint(k)] = fmt.Sprint(v)
+			}
+		}
+	}
+	return routes
+}
+
+var debugTemplate = template.Must(template.New("routes").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Routes</title></head>
+<body>
+<table>
+<tr><th>Methods</th><th>Pattern</th><th>Name</th><th>Meta</th></tr>
+{{range .}}<tr><td>{{range $i, $m := .Methods}}{{if $i}}, {{end}}{{$m}}{{end}}</td><td>{{.Host}}{{.Pattern}}</td><td>{{.Name}}</td><td>{{range $k, $v := .Meta}}{{$k}}={{$v}} {{end}}</td></tr>
+{{end}}</table>
+<p><a href="?format=json">JSON</a> <a href="?format=dot">Graphviz DOT</a></p>
+</body>
+</html>
+`))
+
+// WriteDot writes the router's internal route tries as a Graphviz DOT document, the one for any host first and then
+// one per host, by name. Each node shows its character (or parameter key) and maxParams, nodes holding a route show its
+// pattern.
+func (r *Router) WriteDot(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph routes {")
+	fmt.Fprintln(bw, "\tnode [shape=box, fontname=monospace];")
+	id := 0
+	var walk func(n *node, nodeId int, rootLabel string)
+	walk = func(n *node, nodeId int, rootLabel string) {
+		fmt.Fprintf(bw, "\tn%d [label=\"%s\"", nodeId, dotEscape(dotLabel(n, rootLabel)))
+		if n.route != nil {
+			fmt.Fprint(bw, ", style=bold")
+		}
+		fmt.Fprintln(bw, "];")
+		for _, c := range n.children {
+			id++
+			childId := id
+			fmt.Fprintf(bw, "\tn%d -> n%d;\n", nodeId, childId)
+			walk(c, childId, "")
+		}
+	}
+	table := r.table.Load()
+	walk(&table.trie.root, 0, "root")
+	hosts := make([]string, 0, len(table.hosts))
+	for host := range table.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		id++
+		walk(&table.hosts[host].root, id, "root "+host)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// Labels a node, roots get rootLabel
+func dotLabel(n *node, rootLabel string) string {
+	var label string
+	switch {
+	case rootLabel != "":
+		label = rootLabel
+	case n.paramKey != "":
+		label = string(n.char) + n.paramKey
+	case n.char < 0x20 || n.char >= 0x7f: // Parts of multi-byte characters
+		label = fmt.Sprintf("0x%02X", n.char)
+	default:
+		label = string(n.char)
+	}
+	label += fmt.Sprintf("\nmaxParams=%d", n.maxParams)
+	if n.route != nil {
+		label += "\nroute=" + n.route.Path.UrlPattern
+	}
+	return label
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,144 @@
+package yar
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugHandlerJson(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/user/:user_id", func(w http.ResponseWriter, r *http.Request) {}).Named("user")
+	router.Post("/user/:user_id", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/static/*filepath", func(w http.ResponseWriter, r *http.Request) {}).WithMeta("owner", "web")
+	handler := NewDebugHandler(router)
+	r, _ := http.NewRequest("GET", "/debug/routes?format=json", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(w, r)
+
+	// Assert
+	routes := []debugRoute{}
+	err := json.NewDecoder(w.Body).Decode(&routes)
+	assert.Nil(t, err)
+	assert.Equal(t, []debugRoute{
+		debugRoute{Pattern: "/static/*filepath", Methods: []string{"GET"}, Meta: map[string]string{"owner": "web"}},
+		debugRoute{Pattern: "/user/:user_id", Methods: []string{"GET", "POST"}, Name: "user"},
+	}, routes)
+}
+
+func TestDebugHandlerHtml(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/user/:user_id", func(w http.ResponseWriter, r *http.Request) {}).Named("user")
+	router.Post("/user/:user_id", func(w http.ResponseWriter, r *http.Request) {})
+	handler := NewDebugHandler(router)
+	r, _ := http.NewRequest("GET", "/debug/routes", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(w, r)
+
+	// Assert
+	output, _ := ioutil.ReadAll(w.Result().Body)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, string(output), "<td>GET, POST</td><td>/user/:user_id</td><td>user</td>")
+}
+
+func TestDebugHandlerUnknownFormat(t *testing.T) {
+	// Arrange
+	handler := NewDebugHandler(NewRouter())
+	r, _ := http.NewRequest("GET", "/debug/routes?format=xml", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWriteDot(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/:id", func(w http.ResponseWriter, r *http.Request) {})
+	var buf bytes.Buffer
+
+	// Act
+	err := router.WriteDot(&buf)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, `digraph routes {
+	node [shape=box, fontname=monospace];
+	n0 [label="root\nmaxParams=1"];
+	n0 -> n1;
+	n1 [label="/\nmaxParams=1"];
+	n1 -> n2;
+	n2 [label=":id\nmaxParams=1\nroute=/:id", style=bold];
+}
+`, buf.String())
+}
+
+func TestDebugHandlerIncludesHosts(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/user/:user_id", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleFunc("GET api.example.com/user/{user_id}", func(w http.ResponseWriter, r *http.Request) {})
+	handler := NewDebugHandler(router)
+	r, _ := http.NewRequest("GET", "/debug/routes?format=json", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(w, r)
+
+	// Assert
+	routes := []debugRoute{}
+	err := json.NewDecoder(w.Body).Decode(&routes)
+	assert.Nil(t, err)
+	assert.Equal(t, []debugRoute{
+		debugRoute{Pattern: "/user/:user_id", Methods: []string{"GET"}},
+		debugRoute{Host: "api.example.com", Pattern: "/user/:user_id", Methods: []string{"GET", "HEAD"}},
+	}, routes)
+}
+
+func TestWriteDotIncludesHosts(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/a", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleFunc("GET b.example.com/b", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleFunc("GET a.example.com/a", func(w http.ResponseWriter, r *http.Request) {})
+	var buf bytes.Buffer
+
+	// Act
+	err := router.WriteDot(&buf)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, `digraph routes {
+	node [shape=box, fontname=monospace];
+	n0 [label="root\nmaxParams=0"];
+	n0 -> n1;
+	n1 [label="/\nmaxParams=0"];
+	n1 -> n2;
+	n2 [label="a\nmaxParams=0\nroute=/a", style=bold];
+	n3 [label="root a.example.com\nmaxParams=0"];
+	n3 -> n4;
+	n4 [label="/\nmaxParams=0"];
+	n4 -> n5;
+	n5 [label="a\nmaxParams=0\nroute=/a", style=bold];
+	n6 [label="root b.example.com\nmaxParams=0"];
+	n6 -> n7;
+	n7 [label="/\nmaxParams=0"];
+	n7 -> n8;
+	n8 [label="b\nmaxParams=0\nroute=/b", style=bold];
+}
+`, buf.String())
+}
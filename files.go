@@ -0,0 +1,212 @@
+package yar
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+// FileServerOptions configures Router.ServeFiles
+type FileServerOptions struct {
+	IndexFiles      []string // Served for directories, tried in order. If nil 'index.html' is used, empty disables them.
+	ListDirectories bool     // List directories without an index file, otherwise they are not found
+	Precompressed   bool     // Serve 'name.gz' instead of 'name' when it exists and the client accepts gzip
+}
+
+// ServeFiles serves the files of fsys, e.g. an os.DirFS or embed.FS, under a pattern ending with a wildcard:
+//
+//	router.ServeFiles("/static/*filepath", os.DirFS("public"), nil)
+//
+// The wildcard is the path within fsys, paths that could escape it (e.g. '..') are not found. The directory
+// itself ('/static/') is registered too. Files are served with http.ServeContent, so Range and conditional
+// requests are supported; every file gets an ETag, derived from the content if fsys has no modification times.
+// Directories are redirected to their path ending in '/'. It registers GET and HEAD and returns the wildcard route.
+func (r *Router) ServeFiles(pattern string, fsys fs.FS, opts *FileServerOptions) *Route {
+	p, err := ParsePattern(pattern)
+	if err != nil {
+		panic(err.Error())
+	}
+	last := p.Segments[len(p.Segments)-1]
+	if last.Kind != WildcardSegment {
+		panic(fmt.Sprintf("file server pattern must end with a wildcard, e.g. '/static/*filepath', pattern=%s", pattern))
+	}
+	if opts == nil {
+		opts = &FileServerOptions{}
+	}
+	fileServer := newFileServer(r, fsys, *opts)
+	fileServer.paramKey = last.Value
+
+	directory := pattern[:strings.LastIndex(pattern, "/")+1]
+	var route *Route
+	for _, method := range []string{"GET", "HEAD"} {
+		r.AddHandler(method, directory, fileServer)
+		route = r.AddHandler(method, pattern, fileServer)
+	}
+	return route
+}
+
+type fileServer struct {
+	router   *Router
+	fsys     fs.FS
+	paramKey string // Wildcard holding the file's path
+	opts     FileServerOptions
+
+	etags sync.Map // Content hashes by name, for files without a modification time
+}
+
+func newFileServer(r *Router, fsys fs.FS, opts FileServerOptions) *fileServer {
+	if opts.IndexFiles == nil {
+		opts.IndexFiles = []string{"index.html"}
+	}
+	return &fileServer{router: r, fsys: fsys, opts: opts}
+}
+
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.serve(w, req, GetParam(req, s.paramKey))
+}
+
+// Serves the file or directory at the given path within fsys
+func (s *fileServer) serve(w http.ResponseWriter, req *http.Request, param string) {
+	name, ok := cleanFilePath(param)
+	if !ok {
+		s.router.handleNotFound(w, req)
+		return
+	}
+	info, err := fs.Stat(s.fsys, name)
+	if err != nil {
+		s.router.handleNotFound(w, req)
+		return
+	}
+
+	if info.IsDir() {
+		if param != "" && !strings.HasSuffix(param, "/") {
+			s.redirectToDirectory(w, req)
+			return
+		}
+		for _, index := range s.opts.IndexFiles {
+			indexName := path.Join(name, index)
+			if indexInfo, err := fs.Stat(s.fsys, indexName); err == nil && !indexInfo.IsDir() {
+				s.serveFile(w, req, indexName, indexInfo)
+				return
+			}
+		}
+		if s.opts.ListDirectories {
+			s.listDirectory(w, req, name)
+		} else {
+			s.router.handleNotFound(w, req)
+		}
+		return
+	}
+	s.serveFile(w, req, name, info)
+}
+
+// Turns the wildcard value into a name valid for fs.FS, refusing anything that could leave the directory
+func cleanFilePath(param string) (string, bool) {
+	name := strings.TrimSuffix(param, "/")
+	if name == "" {
+		return ".", true
+	}
+	if strings.ContainsAny(name, "\\\x00") || !fs.ValidPath(name) {
+		return "", false
+	}
+	return name, true
+}
+
+func (s *fileServer) redirectToDirectory(w http.ResponseWriter, req *http.Request) {
+	s.router.logEvent(EventRedirect, req, GetRoute(req), GetParams(req))
+	target := path.Base(req.URL.Path) + "/"
+	if req.URL.RawQuery != "" {
+		target += "?" + req.URL.RawQuery
+	}
+	http.Redirect(w, req, target, http.StatusMovedPermanently)
+}
+
+func (s *fileServer) serveFile(w http.ResponseWriter, req *http.Request, name string, info fs.FileInfo) {
+	header := w.Header()
+	contentName := name // Tells ServeContent the content type
+	if s.opts.Precompressed {
+		addVary(header, "Accept-Encoding")
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType != "" && parseAcceptEncoding(req.Header.Get("Accept-Encoding")).value("gzip") > 0 {
+			if gzInfo, err := fs.Stat(s.fsys, name+".gz"); err == nil && !gzInfo.IsDir() {
+				header.Set("Content-Type", contentType)
+				header.Set("Content-Encoding", "gzip")
+				name, info = name+".gz", gzInfo
+			}
+		}
+	}
+
+	file, err := s.fsys.Open(name)
+	if err != nil {
+		header.Del("Content-Encoding")
+		s.router.handleNotFound(w, req)
+		return
+	}
+	defer file.Close()
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	etag, err := s.etag(name, info, content)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	header.Set("ETag", etag)
+	http.ServeContent(w, req, contentName, info.ModTime(), content)
+}
+
+// Strong ETags from the modification time and size, or from a hash of the content if there is no modification time
+func (s *fileServer) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if !info.ModTime().IsZero() {
+		return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()), nil
+	}
+	if etag, ok := s.etags.Load(name); ok {
+		return etag.(string), nil
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	s.etags.Store(name, etag) // Files without a modification time, like embed.FS ones, don't change
+	return etag, nil
+}
+
+func (s *fileServer) listDirectory(w http.ResponseWriter, req *http.Request, name string) {
+	entries, err := fs.ReadDir(s.fsys, name)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if entry.IsDir() {
+			entryName += "/"
+		}
+		link := url.URL{Path: entryName}
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", html.EscapeString(link.String()), html.EscapeString(entryName))
+	}
+	fmt.Fprintf(w, "</pre>\n")
+}
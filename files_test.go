@@ -0,0 +1,181 @@
+package yar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testModTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func newTestFS() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":       {Data: []byte("<h1>home</h1>"), ModTime: testModTime},
+		"css/site.css":     {Data: []byte("body { color: red }"), ModTime: testModTime},
+		"js/app.js":        {Data: []byte("console.log('app')"), ModTime: testModTime},
+		"js/app.js.gz":     {Data: []byte("gzipped"), ModTime: testModTime},
+		"docs/index.html":  {Data: []byte("docs"), ModTime: testModTime},
+		"embedded.txt":     {Data: []byte("no mod time")},
+		"empty/.gitignore": {Data: []byte("")},
+	}
+}
+
+func TestServeFiles(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.ServeFiles("/static/*filepath", newTestFS(), nil)
+	r, _ := http.NewRequest("GET", "/static/css/site.css", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "body { color: red }", w.Body.String())
+	assert.Equal(t, "text/css; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Thu, 02 Jan 2020 03:04:05 GMT", w.Header().Get("Last-Modified"))
+	assert.Equal(t, `"15e5f2d5e8263200-13"`, w.Header().Get("ETag"))
+}
+
+func TestServeFilesConditionalAndRangeRequests(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.ServeFiles("/static/*filepath", newTestFS(), nil)
+	rNotModified, _ := http.NewRequest("GET", "/static/css/site.css", nil)
+	rNotModified.Header.Set("If-None-Match", `"15e5f2d5e8263200-13"`)
+	rNotModifiedSince, _ := http.NewRequest("GET", "/static/css/site.css", nil)
+	rNotModifiedSince.Header.Set("If-Modified-Since", "Thu, 02 Jan 2020 03:04:05 GMT")
+	rPartial, _ := http.NewRequest("GET", "/static/css/site.css", nil)
+	rPartial.Header.Set("Range", "bytes=0-3")
+	rHead, _ := http.NewRequest("HEAD", "/static/css/site.css", nil)
+	notModified, notModifiedSince, partial, head := httptest.NewRecorder(), httptest.NewRecorder(), httptest.NewRecorder(), httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(notModified, rNotModified)
+	router.ServeHTTP(notModifiedSince, rNotModifiedSince)
+	router.ServeHTTP(partial, rPartial)
+	router.ServeHTTP(head, rHead)
+
+	// Assert
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Equal(t, http.StatusNotModified, notModifiedSince.Code)
+	assert.Equal(t, http.StatusPartialContent, partial.Code)
+	assert.Equal(t, "body", partial.Body.String())
+	assert.Equal(t, "bytes 0-3/19", partial.Header().Get("Content-Range"))
+	assert.Equal(t, http.StatusOK, head.Code)
+	assert.Equal(t, "19", head.Header().Get("Content-Length"))
+	assert.Equal(t, "", head.Body.String())
+}
+
+func TestServeFilesDirectories(t *testing.T) {
+	tcs := []struct {
+		opts             *FileServerOptions
+		path             string
+		expectedCode     int
+		expectedBody     string
+		expectedLocation string
+	}{
+		{nil, "/static/", http.StatusOK, "<h1>home</h1>", ""},
+		{nil, "/static/docs/", http.StatusOK, "docs", ""},
+		{nil, "/static/docs", http.StatusMovedPermanently, "", "/static/docs/"},
+		{nil, "/static/css/", http.StatusNotFound, "Not Found\n", ""},
+		{&FileServerOptions{IndexFiles: []string{}}, "/static/docs/", http.StatusNotFound, "Not Found\n", ""},
+		{&FileServerOptions{ListDirectories: true}, "/static/css/", http.StatusOK,
+			"<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n<a href=\"site.css\">site.css</a>\n</pre>\n", ""},
+	}
+
+	for _, tc := range tcs {
+		// Arrange
+		router := NewRouter()
+		router.ServeFiles("/static/*filepath", newTestFS(), tc.opts)
+		r, _ := http.NewRequest("GET", tc.path, nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		router.ServeHTTP(w, r)
+
+		// Assert
+		assert.Equal(t, tc.expectedCode, w.Code, tc.path)
+		if tc.expectedBody != "" {
+			assert.Equal(t, tc.expectedBody, w.Body.String(), tc.path)
+		}
+		assert.Equal(t, tc.expectedLocation, w.Header().Get("Location"), tc.path)
+	}
+}
+
+func TestServeFilesRejectsTraversal(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.ServeFiles("/static/*filepath", fstest.MapFS{"public/a.txt": {Data: []byte("a")}, "secret.txt": {Data: []byte("secret")}}, nil)
+	paths := []string{"/static/../secret.txt", "/static/a/../../secret.txt", "/static/..%2fsecret.txt", "/static//secret.txt", "/static/.\\secret.txt"}
+
+	for _, path := range paths {
+		// Arrange
+		r, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		router.ServeHTTP(w, r)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+	}
+}
+
+func TestServeFilesPrecompressed(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.ServeFiles("/static/*filepath", newTestFS(), &FileServerOptions{Precompressed: true})
+
+	rGzipped, _ := http.NewRequest("GET", "/static/js/app.js", nil)
+	rGzipped.Header.Set("Accept-Encoding", "gzip, deflate")
+	rPlain, _ := http.NewRequest("GET", "/static/js/app.js", nil)
+	rPlain.Header.Set("Accept-Encoding", "gzip;q=0")
+	gzipped, plain := httptest.NewRecorder(), httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(gzipped, rGzipped)
+	router.ServeHTTP(plain, rPlain)
+
+	// Assert
+	assert.Equal(t, "gzipped", gzipped.Body.String())
+	assert.Equal(t, "gzip", gzipped.Header().Get("Content-Encoding"))
+	assert.Equal(t, "text/javascript; charset=utf-8", gzipped.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept-Encoding", gzipped.Header().Get("Vary"))
+	assert.Equal(t, "console.log('app')", plain.Body.String())
+	assert.Equal(t, "", plain.Header().Get("Content-Encoding"))
+}
+
+func TestServeFilesHashesContentWithoutModTime(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.ServeFiles("/*filepath", newTestFS(), nil)
+
+	r1, _ := http.NewRequest("GET", "/embedded.txt", nil)
+	r2, _ := http.NewRequest("GET", "/embedded.txt", nil)
+	r2.Header.Set("If-None-Match", `"0b5329ebe1839d373bd582a61a965271"`)
+	w1, w2 := httptest.NewRecorder(), httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w1, r1)
+	router.ServeHTTP(w2, r2)
+
+	// Assert
+	assert.Equal(t, `"0b5329ebe1839d373bd582a61a965271"`, w1.Header().Get("ETag"))
+	assert.Equal(t, "", w1.Header().Get("Last-Modified"))
+	assert.Equal(t, "no mod time", w1.Body.String())
+	assert.Equal(t, http.StatusNotModified, w2.Code)
+}
+
+func TestServeFilesPanicsWithoutWildcard(t *testing.T) {
+	router := NewRouter()
+
+	assert.Panics(t, func() {
+		router.ServeFiles("/static/:file", newTestFS(), nil)
+	})
+}
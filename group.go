@@ -0,0 +1,73 @@
+package yar
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Group registers routes under a common path prefix, wrapping their handlers in the group's middlewares:
+//
+//	api := router.Group("/api").Use(router.Timeout(5 * time.Second))
+//	api.Get("/users/:id", getUser) // Registered as '/api/users/:id'
+type Group struct {
+	router      *Router
+	prefix      string
+	middlewares []Middleware
+}
+
+// Group creates a route group, the prefix must start with '/' and may end with one
+func (r *Router) Group(prefix string) *Group {
+	return &Group{router: r, prefix: strings.TrimSuffix(prefix, "/")}
+}
+
+// Group creates a nested group, inheriting the group's prefix and middlewares
+func (g *Group) Group(prefix string) *Group {
+	return &Group{
+		router:      g.router,
+		prefix:      g.prefix + strings.TrimSuffix(prefix, "/"),
+		middlewares: g.middlewares[:len(g.middlewares):len(g.middlewares)], // Appending must not affect this group
+	}
+}
+
+// Use adds middlewares around the handlers registered through the group from now on.
+// They run inside the router's and the route's own middlewares, the first one added is the outermost.
+func (g *Group) Use(middlewares ...Middleware) *Group {
+	g.middlewares = append(g.middlewares, middlewares...)
+	return g
+}
+
+func (g *Group) AddHandler(method, path string, handler http.Handler) *Route {
+	return g.router.AddHandler(method, g.prefix+path, chain(handler, g.middlewares))
+}
+
+func (g *Group) AddHandleFunc(method, path string, handlerFunc http.HandlerFunc) *Route {
+	return g.AddHandler(method, path, handlerFunc)
+}
+
+func (g *Group) AddHandle(method, path string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return g.AddHandler(method, path, http.HandlerFunc(handlerFunc))
+}
+
+func (g *Group) Head(path string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return g.AddHandle("HEAD", path, handlerFunc)
+}
+
+func (g *Group) Get(path string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return g.AddHandle("GET", path, handlerFunc)
+}
+
+func (g *Group) Post(path string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return g.AddHandle("POST", path, handlerFunc)
+}
+
+func (g *Group) Put(path string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return g.AddHandle("PUT", path, handlerFunc)
+}
+
+func (g *Group) Patch(path string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return g.AddHandle("PATCH", path, handlerFunc)
+}
+
+func (g *Group) Delete(path string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return g.AddHandle("DELETE", path, handlerFunc)
+}
@@ -0,0 +1,66 @@
+package yar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupPrefixesAndMiddlewares(t *testing.T) {
+	// Arrange
+	calls := []string{}
+	trace := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	handler := func(name string) func(http.ResponseWriter, *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, name+":"+GetParam(r, "id"))
+		}
+	}
+	router := NewRouter()
+	api := router.Group("/api/").Use(trace("api"))
+	admin := api.Group("/admin").Use(trace("admin"))
+	api.Get("/users/:id", handler("user"))
+	admin.Delete("/users/:id", handler("delete")).Use(trace("route"))
+	api.Use(trace("late")) // Only affects routes registered afterwards, not the admin group
+	api.Post("/users", handler("create"))
+
+	tcs := []struct {
+		method, path string
+		expected     []string
+	}{
+		{"GET", "/api/users/1", []string{"api", "user:1"}},
+		{"DELETE", "/api/admin/users/2", []string{"route", "api", "admin", "delete:2"}},
+		{"POST", "/api/users", []string{"api", "late", "create:"}},
+	}
+
+	for _, tc := range tcs {
+		// Act
+		calls = []string{}
+		r, _ := http.NewRequest(tc.method, tc.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code, tc.path)
+		assert.Equal(t, tc.expected, calls, tc.path)
+	}
+}
+
+func TestGroupRoutesArePlainRoutes(t *testing.T) {
+	router := NewRouter()
+	router.Group("/v1").Get("/items", func(w http.ResponseWriter, r *http.Request) {}).Named("items")
+
+	routes := router.Routes()
+
+	assert.Equal(t, 1, len(routes))
+	assert.Equal(t, "/v1/items", routes[0].Pattern)
+	assert.Equal(t, "items", routes[0].Name)
+}
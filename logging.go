@@ -0,0 +1,118 @@
+package yar
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// EventKind tells what the router did with a request
+type EventKind string
+
+const (
+	EventMatch            EventKind = "match"              // Found a route and method handler
+	EventNotFound         EventKind = "not_found"          // No route matches the path
+	EventMethodNotAllowed EventKind = "method_not_allowed" // Route found, but not the method handler
+	EventOptions          EventKind = "options"            // Answered an OPTIONS request automatically
+	EventRedirect         EventKind = "redirect"           // Redirected the request to another path
+	EventPanic            EventKind = "panic"              // Recovered a panic, see Router.PanicHandler and Router.Timeout
+	EventTimeout          EventKind = "timeout"            // A handler did not finish in time, see Router.Timeout
+)
+
+// Event is passed to the router's Logger for every routing decision
+type Event struct {
+	Kind    EventKind
+	Request *http.Request
+	Route   *Route // nil if no route was found
+	Params  Params
+	Panic   interface{}   // Recovered value, for EventPanic
+	Stack   []byte        // Stack of the panicking goroutine, for EventPanic
+	Timeout time.Duration // Expired timeout, for EventTimeout
+}
+
+// Logger receives the router's events, e.g. to write them to a structured log
+type Logger interface {
+	LogEvent(e Event)
+}
+
+// LoggerFunc allows using a simple function as a Logger
+type LoggerFunc func(e Event)
+
+func (f LoggerFunc) LogEvent(e Event) {
+	f(e)
+}
+
+// SlogLogger writes events to a *slog.Logger, or to slog.Default() if none is set. Panics are logged as errors and timeouts as warnings.
+type SlogLogger struct {
+	Logger *slog.Logger
+	Level  slog.Level
+}
+
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	return &SlogLogger{Logger: logger, Level: slog.LevelInfo}
+}
+
+func (l *SlogLogger) LogEvent(e Event) {
+	logger := l.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	level := l.Level
+	switch e.Kind {
+	case EventPanic:
+		level = slog.LevelError
+	case EventTimeout:
+		level = slog.LevelWarn
+	}
+	ctx := e.Request.Context()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	logger.LogAttrs(ctx, level, "yar: "+string(e.Kind), EventAttrs(e)...)
+}
+
+// EventAttrs returns the event's request and route details as slog attributes
+func EventAttrs(e Event) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("event", string(e.Kind)),
+		slog.String("method", e.Request.Method),
+		slog.String("path", e.Request.URL.Path),
+	}
+	if e.Route != nil {
+		attrs = append(attrs, slog.String("pattern", e.Route.Path.UrlPattern))
+		if e.Route.Name != "" {
+			attrs = append(attrs, slog.String("route", e.Route.Name))
+		}
+	}
+	if len(e.Params) > 0 {
+		params := make([]any, len(e.Params))
+		for i, p := range e.Params {
+			params[i] = slog.String(p.Key, p.Value)
+		}
+		attrs = append(attrs, slog.Group("params", params...))
+	}
+	if e.Timeout > 0 {
+		attrs = append(attrs, slog.Duration("timeout", e.Timeout))
+	}
+	if e.Panic != nil {
+		attrs = append(attrs, slog.Any("panic", e.Panic), slog.String("stack", string(e.Stack)))
+	}
+	return attrs
+}
+
+var defaultLogger = NewSlogLogger(nil)
+
+func (r *Router) logEvent(kind EventKind, req *http.Request, route *Route, params Params) {
+	r.emitEvent(Event{Kind: kind, Request: req, Route: route, Params: params})
+}
+
+func (r *Router) emitEvent(e Event) {
+	logger := r.Logger
+	if logger == nil {
+		if !r.ShouldLog {
+			return
+		}
+		logger = defaultLogger
+	}
+	logger.LogEvent(e)
+}
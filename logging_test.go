@@ -0,0 +1,69 @@
+package yar
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerReceivesEvents(t *testing.T) {
+	// Arrange
+	events := []Event{}
+	router := NewRouter()
+	router.ShouldHandleOptions = true
+	router.Logger = LoggerFunc(func(e Event) { events = append(events, e) })
+	router.Get("/user/:id", func(w http.ResponseWriter, r *http.Request) {}).Named("user")
+
+	// Act
+	for _, req := range [][2]string{{"GET", "/user/1"}, {"POST", "/user/2"}, {"OPTIONS", "/user/3"}, {"GET", "/missing"}} {
+		r, _ := http.NewRequest(req[0], req[1], nil)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	// Assert
+	assert.Equal(t, 4, len(events))
+	assert.Equal(t, EventMatch, events[0].Kind)
+	assert.Equal(t, "user", events[0].Route.Name)
+	assert.Equal(t, Params{Param{"id", "1"}}, events[0].Params)
+	assert.Equal(t, EventMethodNotAllowed, events[1].Kind)
+	assert.Equal(t, EventOptions, events[2].Kind)
+	assert.Equal(t, EventNotFound, events[3].Kind)
+	assert.Nil(t, events[3].Route)
+}
+
+func TestSlogLogger(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	router := NewRouter()
+	router.Logger = NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	router.Get("/user/:id", func(w http.ResponseWriter, r *http.Request) {}).Named("user")
+	r, _ := http.NewRequest("GET", "/user/1", nil)
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	// Assert
+	assert.Contains(t, buf.String(), `msg="yar: match" event=match method=GET path=/user/1 pattern=/user/:id route=user params.id=1`)
+}
+
+func TestNoLoggingByDefault(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	defaultSlog := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(defaultSlog)
+	router := NewRouter()
+	r, _ := http.NewRequest("GET", "/missing", nil)
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), r)
+	router.ShouldLog = true
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	// Assert
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("event=not_found")))
+}
@@ -0,0 +1,42 @@
+package yar
+
+import "net/http"
+
+// Metadata holds arbitrary values describing a route, keyed like context.Context values.
+// To avoid collisions between packages, keys should be of an unexported type:
+//
+//	type scopesKey struct{}
+//	router.Get("/admin", handler).WithMeta(scopesKey{}, []string{"admin"})
+type Metadata map[interface{}]interface{}
+
+// WithMeta sets a metadata value for all of the route's methods
+func (rt *Route) WithMeta(key, value interface{}) *Route {
+	rt.Meta[key] = value
+	return rt
+}
+
+// WithMethodMeta sets a metadata value for one of the route's methods, overriding the route wide value
+func (rt *Route) WithMethodMeta(method string, key, value interface{}) *Route {
+	if rt.MethodMeta[method] == nil {
+		rt.MethodMeta[method] = make(Metadata)
+	}
+	rt.MethodMeta[method][key] = value
+	return rt
+}
+
+// MetaValue returns the metadata value for the given method, falling back to the route wide value
+func (rt *Route) MetaValue(method string, key interface{}) interface{} {
+	if value, ok := rt.MethodMeta[method][key]; ok {
+		return value
+	}
+	return rt.Meta[key]
+}
+
+// GetMeta returns the matched route's metadata value for the request's method, nil if not set
+func GetMeta(r *http.Request, key interface{}) interface{} {
+	route := GetRoute(r)
+	if route == nil {
+		return nil
+	}
+	return route.MetaValue(r.Method, key)
+}
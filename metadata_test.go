@@ -0,0 +1,66 @@
+package yar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testOwnerKey struct{}
+type testScopesKey struct{}
+
+func TestRouteMetaValue(t *testing.T) {
+	route := NewRoute("/user/:id").
+		WithMeta(testOwnerKey{}, "accounts").
+		WithMeta(testScopesKey{}, []string{"read"}).
+		WithMethodMeta("DELETE", testScopesKey{}, []string{"admin"})
+
+	assert.Equal(t, "accounts", route.MetaValue("GET", testOwnerKey{}))
+	assert.Equal(t, "accounts", route.MetaValue("DELETE", testOwnerKey{}))
+	assert.Equal(t, []string{"read"}, route.MetaValue("GET", testScopesKey{}))
+	assert.Equal(t, []string{"admin"}, route.MetaValue("DELETE", testScopesKey{}))
+	assert.Nil(t, route.MetaValue("GET", "missing"))
+}
+
+func TestGetMetaInsideMiddleware(t *testing.T) {
+	// Arrange
+	requireScope := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scopes, _ := GetMeta(r, testScopesKey{}).([]string)
+			if len(scopes) > 0 && r.Header.Get("X-Scope") != scopes[0] {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(GetMeta(r, testOwnerKey{}).(string)))
+	})
+	router := NewRouter()
+	router.ShouldLog = false
+	router.AddHandler("GET", "/static", requireScope(handler)).
+		WithMeta(testOwnerKey{}, "web").
+		WithMethodMeta("GET", testScopesKey{}, []string{"read"})
+	rAllowed, _ := http.NewRequest("GET", "/static", nil)
+	rAllowed.Header.Set("X-Scope", "read")
+	wAllowed := httptest.NewRecorder()
+	rForbidden, _ := http.NewRequest("GET", "/static", nil)
+	wForbidden := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(wAllowed, rAllowed)
+	router.ServeHTTP(wForbidden, rForbidden)
+
+	// Assert
+	assert.Equal(t, "web", wAllowed.Body.String())
+	assert.Equal(t, http.StatusForbidden, wForbidden.Code)
+}
+
+func TestGetMetaWithoutRoute(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+
+	assert.Nil(t, GetMeta(r, testOwnerKey{}))
+}
@@ -0,0 +1,187 @@
+package yar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMetricsBuckets are the latency histogram's upper bounds in seconds, the same as Prometheus client defaults
+var DefaultMetricsBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics counts requests, requests in flight and request latency by route pattern, method and status class
+// (2xx, 4xx, ...), and serves them in the Prometheus text exposition format.
+//
+// Add it to a router with Use, so it runs after the route has been matched, and mount it to be scraped:
+//
+//	metrics := yar.NewMetrics(nil)
+//	router.Use(metrics.Middleware)
+//	router.AddHandler("GET", "/metrics", metrics)
+type Metrics struct {
+	buckets []float64
+	now     func() time.Time
+
+	mu       sync.Mutex
+	requests map[metricsKey]*metricsSeries
+	inFlight map[metricsKey]int64 // Keyed without the status class
+}
+
+type metricsKey struct {
+	method  string
+	pattern string
+	status  string
+}
+
+type metricsSeries struct {
+	count   uint64
+	sum     float64
+	buckets []uint64 // Cumulative counts, one per upper bound
+}
+
+// NewMetrics creates metrics using the given histogram buckets, DefaultMetricsBuckets if nil
+func NewMetrics(buckets []float64) *Metrics {
+	if buckets == nil {
+		buckets = DefaultMetricsBuckets
+	}
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+	return &Metrics{
+		buckets:  buckets,
+		now:      time.Now,
+		requests: make(map[metricsKey]*metricsSeries),
+		inFlight: make(map[metricsKey]int64),
+	}
+}
+
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		key := metricsKey{method: metricsMethod(req.Method), pattern: "-"}
+		if route := GetRoute(req); route != nil {
+			key.pattern = route.Path.UrlPattern
+		}
+		m.mu.Lock()
+		m.inFlight[key]++
+		m.mu.Unlock()
+
+		start := m.now()
+		rw := newResponseWriter(w)
+		defer func() {
+			if p := recover(); p != nil { // Counted as the 500 Internal Server Error a recovered panic gets
+				m.observe(key, http.StatusInternalServerError, m.now().Sub(start))
+				panic(p)
+			}
+			m.observe(key, rw.status, m.now().Sub(start))
+		}()
+		next.ServeHTTP(rw.writer(), req)
+	})
+}
+
+func (m *Metrics) observe(key metricsKey, status int, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inFlight[key]--
+	key.status = fmt.Sprintf("%dxx", status/100)
+	series := m.requests[key]
+	if series == nil {
+		series = &metricsSeries{buckets: make([]uint64, len(m.buckets))}
+		m.requests[key] = series
+	}
+	seconds := latency.Seconds()
+	series.count++
+	series.sum += seconds
+	for i, upperBound := range m.buckets {
+		if seconds <= upperBound {
+			series.buckets[i]++
+		}
+	}
+}
+
+// Only well known methods get their own label value, so clients cannot create new series at will
+func metricsMethod(method string) string {
+	switch method {
+	case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "CONNECT", "OPTIONS", "TRACE":
+		return method
+	}
+	return "OTHER"
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteText(w)
+}
+
+// WriteText writes all metrics in the Prometheus text exposition format
+func (m *Metrics) WriteText(out io.Writer) error {
+	w := bufio.NewWriter(out)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]metricsKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sortMetricsKeys(keys)
+
+	fmt.Fprintln(w, "# HELP yar_http_requests_total Total number of HTTP requests by route pattern, method and status class.")
+	fmt.Fprintln(w, "# TYPE yar_http_requests_total counter")
+	for _, key := range keys {
+		fmt.Fprintf(w, "yar_http_requests_total{%s} %d\n", key.labels(), m.requests[key].count)
+	}
+
+	fmt.Fprintln(w, "# HELP yar_http_requests_in_flight Number of HTTP requests currently being served by route pattern and method.")
+	fmt.Fprintln(w, "# TYPE yar_http_requests_in_flight gauge")
+	inFlightKeys := make([]metricsKey, 0, len(m.inFlight))
+	for key := range m.inFlight {
+		inFlightKeys = append(inFlightKeys, key)
+	}
+	sortMetricsKeys(inFlightKeys)
+	for _, key := range inFlightKeys {
+		fmt.Fprintf(w, "yar_http_requests_in_flight{%s} %d\n", key.labels(), m.inFlight[key])
+	}
+
+	fmt.Fprintln(w, "# HELP yar_http_request_duration_seconds HTTP request latency by route pattern, method and status class.")
+	fmt.Fprintln(w, "# TYPE yar_http_request_duration_seconds histogram")
+	for _, key := range keys {
+		series := m.requests[key]
+		labels := key.labels()
+		for i, upperBound := range m.buckets {
+			le := strconv.FormatFloat(upperBound, 'g', -1, 64)
+			fmt.Fprintf(w, "yar_http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, le, series.buckets[i])
+		}
+		fmt.Fprintf(w, "yar_http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, series.count)
+		fmt.Fprintf(w, "yar_http_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(series.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "yar_http_request_duration_seconds_count{%s} %d\n", labels, series.count)
+	}
+	return w.Flush()
+}
+
+func sortMetricsKeys(keys []metricsKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].pattern != keys[j].pattern {
+			return keys[i].pattern < keys[j].pattern
+		}
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].status < keys[j].status
+	})
+}
+
+func (k metricsKey) labels() string {
+	labels := fmt.Sprintf(`method="%s",pattern="%s"`, escapeLabel(k.method), escapeLabel(k.pattern))
+	if k.status != "" {
+		labels += fmt.Sprintf(`,status="%s"`, k.status)
+	}
+	return labels
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
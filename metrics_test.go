@@ -0,0 +1,89 @@
+package yar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	// Arrange
+	metrics := NewMetrics([]float64{0.1, 0.01})
+	clock := time.Unix(0, 0)
+	metrics.now = func() time.Time {
+		clock = clock.Add(25 * time.Millisecond)
+		return clock
+	}
+	router := NewRouter()
+	router.Use(metrics.Middleware)
+	router.Get("/user/:id", func(w http.ResponseWriter, r *http.Request) {
+		if GetParam(r, "id") == "0" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+
+	// Act
+	for _, req := range [][2]string{{"GET", "/user/1"}, {"GET", "/user/2"}, {"GET", "/user/0"}, {"BREW", "/coffee"}} {
+		r, _ := http.NewRequest(req[0], req[1], nil)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/metrics", nil)
+	metrics.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `# HELP yar_http_requests_total Total number of HTTP requests by route pattern, method and status class.
+# TYPE yar_http_requests_total counter
+yar_http_requests_total{method="OTHER",pattern="-",status="4xx"} 1
+yar_http_requests_total{method="GET",pattern="/user/:id",status="2xx"} 2
+yar_http_requests_total{method="GET",pattern="/user/:id",status="4xx"} 1
+# HELP yar_http_requests_in_flight Number of HTTP requests currently being served by route pattern and method.
+# TYPE yar_http_requests_in_flight gauge
+yar_http_requests_in_flight{method="OTHER",pattern="-"} 0
+yar_http_requests_in_flight{method="GET",pattern="/user/:id"} 0
+# HELP yar_http_request_duration_seconds HTTP request latency by route pattern, method and status class.
+# TYPE yar_http_request_duration_seconds histogram
+yar_http_request_duration_seconds_bucket{method="OTHER",pattern="-",status="4xx",le="0.01"} 0
+yar_http_request_duration_seconds_bucket{method="OTHER",pattern="-",status="4xx",le="0.1"} 1
+yar_http_request_duration_seconds_bucket{method="OTHER",pattern="-",status="4xx",le="+Inf"} 1
+yar_http_request_duration_seconds_sum{method="OTHER",pattern="-",status="4xx"} 0.025
+yar_http_request_duration_seconds_count{method="OTHER",pattern="-",status="4xx"} 1
+yar_http_request_duration_seconds_bucket{method="GET",pattern="/user/:id",status="2xx",le="0.01"} 0
+yar_http_request_duration_seconds_bucket{method="GET",pattern="/user/:id",status="2xx",le="0.1"} 2
+yar_http_request_duration_seconds_bucket{method="GET",pattern="/user/:id",status="2xx",le="+Inf"} 2
+yar_http_request_duration_seconds_sum{method="GET",pattern="/user/:id",status="2xx"} 0.05
+yar_http_request_duration_seconds_count{method="GET",pattern="/user/:id",status="2xx"} 2
+yar_http_request_duration_seconds_bucket{method="GET",pattern="/user/:id",status="4xx",le="0.01"} 0
+yar_http_request_duration_seconds_bucket{method="GET",pattern="/user/:id",status="4xx",le="0.1"} 1
+yar_http_request_duration_seconds_bucket{method="GET",pattern="/user/:id",status="4xx",le="+Inf"} 1
+yar_http_request_duration_seconds_sum{method="GET",pattern="/user/:id",status="4xx"} 0.025
+yar_http_request_duration_seconds_count{method="GET",pattern="/user/:id",status="4xx"} 1
+`, w.Body.String())
+}
+
+func TestMetricsCountsPanicsAsServerErrors(t *testing.T) {
+	// Arrange
+	metrics := NewMetrics(nil)
+	router := NewRouter()
+	router.PanicHandler = DefaultPanicHandler
+	router.Use(metrics.Middleware)
+	router.Get("/panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	r, _ := http.NewRequest("GET", "/panic", nil)
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), r)
+	w := httptest.NewRecorder()
+	metrics.ServeHTTP(w, r)
+
+	// Assert
+	assert.Contains(t, w.Body.String(), `yar_http_requests_total{method="GET",pattern="/panic",status="5xx"} 1`)
+	assert.Contains(t, w.Body.String(), `yar_http_requests_in_flight{method="GET",pattern="/panic"} 0`)
+}
+
+func TestEscapeLabel(t *testing.T) {
+	assert.Equal(t, `a\"b\\c\nd`, escapeLabel("a\"b\\c\nd"))
+}
@@ -0,0 +1,56 @@
+package yar
+
+import "net/http"
+
+// Middleware wraps a handler, e.g. to add logging or authentication
+type Middleware func(http.Handler) http.Handler
+
+// Use adds middlewares around every request the router serves, including not found and method not allowed ones.
+// They run after the route has been matched, so GetParams and GetMeta already work inside them.
+// The first middleware added is the outermost one.
+func (r *Router) Use(middlewares ...Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+	r.handler = chain(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rc := getRouteContext(req)
+		r.dispatch(w, req, rc.route, rc.params)
+	}), r.middlewares)
+}
+
+// Use adds middlewares around all of the route's method handlers, including ones registered later.
+// They run after the router's middlewares, the first middleware added is the outermost one.
+func (rt *Route) Use(middlewares ...Middleware) *Route {
+	rt.middlewares = append(rt.middlewares, middlewares...)
+	rt.buildChains()
+	return rt
+}
+
+// Wraps every method handler in the route's middlewares, once at registration rather than per request
+func (rt *Route) buildChains() {
+	if len(rt.middlewares) == 0 {
+		return
+	}
+	rt.chains = make(map[string]http.Handler, len(rt.Handlers))
+	for method, handler := range rt.Handlers {
+		rt.chains[method] = chain(handler, rt.middlewares)
+	}
+}
+
+// Returns the method's handler, wrapped in the route's middlewares, falling back to the AnyMethod handler
+func (rt *Route) handler(method string) http.Handler {
+	handlers := rt.Handlers
+	if rt.chains != nil {
+		handlers = rt.chains
+	}
+	if handler := handlers[method]; handler != nil {
+		return handler
+	}
+	return handlers[AnyMethod]
+}
+
+// Wraps handler in the middlewares, the first one being the outermost
+func chain(handler http.Handler, middlewares []Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
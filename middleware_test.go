@@ -0,0 +1,76 @@
+package yar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseRunsMiddlewaresInOrderAfterMatching(t *testing.T) {
+	// Arrange
+	calls := []string{}
+	trace := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name+":"+GetParam(r, "id"))
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	router := NewRouter()
+	router.Use(trace("first"))
+	router.Get("/user/:id", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})
+	router.Use(trace("second"))
+	r, _ := http.NewRequest("GET", "/user/7", nil)
+	rNotFound, _ := http.NewRequest("GET", "/missing", nil)
+	wNotFound := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), r)
+	router.ServeHTTP(wNotFound, rNotFound)
+
+	// Assert
+	assert.Equal(t, []string{"first:7", "second:7", "handler", "first:", "second:"}, calls)
+	assert.Equal(t, http.StatusNotFound, wNotFound.Code)
+}
+
+func TestRouteUseWrapsAllMethods(t *testing.T) {
+	// Arrange
+	calls := []string{}
+	router := NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, "router")
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.Get("/user/:id", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "get")
+	}).Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, "route:"+GetParam(r, "id"))
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.Delete("/user/:id", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "delete")
+	})
+	router.Get("/other", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "other")
+	})
+	rGet, _ := http.NewRequest("GET", "/user/1", nil)
+	rDelete, _ := http.NewRequest("DELETE", "/user/2", nil)
+	rOther, _ := http.NewRequest("GET", "/other", nil)
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), rGet)
+	router.ServeHTTP(httptest.NewRecorder(), rDelete)
+	router.ServeHTTP(httptest.NewRecorder(), rOther)
+
+	// Assert
+	assert.Equal(t, []string{"router", "route:1", "get", "router", "route:2", "delete", "router", "other"}, calls)
+}
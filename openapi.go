@@ -0,0 +1,602 @@
+package yar
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a raw JSON schema object, e.g. Schema{"type": "string"}
+type Schema map[string]interface{}
+
+type OpenAPIDocument struct {
+	OpenAPI string                      `json:"openapi"`
+	Info    OpenAPIInfo                 `json:"info"`
+	Paths   map[string]*OpenAPIPathItem `json:"paths"`
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type OpenAPIPathItem struct {
+	Get     *OpenAPIOperation `json:"get,omitempty"`
+	Put     *OpenAPIOperation `json:"put,omitempty"`
+	Post    *OpenAPIOperation `json:"post,omitempty"`
+	Delete  *OpenAPIOperation `json:"delete,omitempty"`
+	Options *OpenAPIOperation `json:"options,omitempty"`
+	Head    *OpenAPIOperation `json:"head,omitempty"`
+	Patch   *OpenAPIOperation `json:"patch,omitempty"`
+	Trace   *OpenAPIOperation `json:"trace,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+	Servers     []OpenAPIServer             `json:"servers,omitempty"` // Where the operation is served, if not everywhere
+}
+
+type OpenAPIServer struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type OpenAPIParameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"` // path, query, header or cookie
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Schema      Schema `json:"schema,omitempty"`
+}
+
+type OpenAPIRequestBody struct {
+	Description string                      `json:"description,omitempty"`
+	Required    bool                        `json:"required,omitempty"`
+	Content     map[string]OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema Schema `json:"schema,omitempty"`
+}
+
+// Operations returns the path item's operations keyed by upper case method
+func (pi *OpenAPIPathItem) Operations() map[string]*OpenAPIOperation {
+	ops := make(map[string]*OpenAPIOperation)
+	for method, op := range map[string]*OpenAPIOperation{
+		"GET": pi.Get, "PUT": pi.Put, "POST": pi.Post, "DELETE": pi.Delete,
+		"OPTIONS": pi.Options, "HEAD": pi.Head, "PATCH": pi.Patch, "TRACE": pi.Trace,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+// SetOperation stores op under the given method, returning false for methods OpenAPI cannot describe
+func (pi *OpenAPIPathItem) SetOperation(method string, op *OpenAPIOperation) bool {
+	switch strings.ToUpper(method) {
+	case "GET":
+		pi.Get = op
+	case "PUT":
+		pi.Put = op
+	case "POST":
+		pi.Post = op
+	case "DELETE":
+		pi.Delete = op
+	case "OPTIONS":
+		pi.Options = op
+	case "HEAD":
+		pi.Head = op
+	case "PATCH":
+		pi.Patch = op
+	case "TRACE":
+		pi.Trace = op
+	default:
+		return false
+	}
+	return true
+}
+
+// OpenAPIOperationKey is the method metadata key under which Describe stores the operation
+type OpenAPIOperationKey struct{}
+
+// Describe attaches OpenAPI documentation to one of the route's methods
+func (rt *Route) Describe(method string, op OpenAPIOperation) *Route {
+	return rt.WithMethodMeta(method, OpenAPIOperationKey{}, op)
+}
+
+// OpenAPIPath converts a yar pattern to an OpenAPI path template, e.g. '/user/:id' to '/user/{id}'
+func OpenAPIPath(pattern *Pattern) string {
+	parts := make([]string, len(pattern.Segments))
+	for i, s := range pattern.Segments {
+		parts[i] = s.Value
+		if s.Kind != StaticSegment {
+			parts[i] = "{" + s.Value + "}"
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// OpenAPI generates an OpenAPI 3 document with one operation per registered method handler. Operations of routes
+// registered with a host list it in their servers, e.g. '//api.example.com'. A path and method registered under several
+// hosts is documented once, for any host first and then by host name; see OpenAPIHost for what a single host serves.
+func (r *Router) OpenAPI(info OpenAPIInfo) *OpenAPIDocument {
+	doc := newOpenAPIDocument(info)
+	for _, ri := range r.Routes() { // Routes for any host come first
+		var servers []OpenAPIServer
+		if ri.Host != "" {
+			servers = []OpenAPIServer{{URL: "//" + ri.Host}}
+		}
+		addOpenAPIOperations(doc, ri.Route, servers)
+	}
+	return doc
+}
+
+// OpenAPIHost generates an OpenAPI 3 document of the routes serving requests for host: the ones registered with it and
+// the ones for any host whose path it does not take over.
+func (r *Router) OpenAPIHost(host string, info OpenAPIInfo) *OpenAPIDocument {
+	doc := newOpenAPIDocument(info)
+	table := r.table.Load()
+	if hostTrie := table.hosts[host]; hostTrie != nil {
+		for _, route := range hostTrie.Routes() {
+			addOpenAPIOperations(doc, route, nil)
+		}
+	}
+	routes := table.trie.Routes()
+	for _, spa := range r.spas {
+		routes = append(routes, spa.route)
+	}
+	for _, route := range routes {
+		if doc.Paths[OpenAPIPath(route.Path.Pattern)] == nil {
+			addOpenAPIOperations(doc, route, nil)
+		}
+	}
+	return doc
+}
+
+func newOpenAPIDocument(info OpenAPIInfo) *OpenAPIDocument {
+	return &OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   make(map[string]*OpenAPIPathItem),
+	}
+}
+
+// Adds the route's operations to its path item, methods already documented are kept
+func addOpenAPIOperations(doc *OpenAPIDocument, route *Route, servers []OpenAPIServer) {
+	path := OpenAPIPath(route.Path.Pattern)
+	item := doc.Paths[path]
+	if item == nil {
+		item = &OpenAPIPathItem{}
+	}
+	documented := item.Operations()
+	for _, method := range route.Methods() {
+		if documented[strings.ToUpper(method)] != nil {
+			continue
+		}
+		op := newOpenAPIOperation(route, method)
+		if op.Servers == nil {
+			op.Servers = servers
+		}
+		item.SetOperation(method, op)
+	}
+	if len(item.Operations()) > 0 {
+		doc.Paths[path] = item
+	}
+}
+
+func newOpenAPIOperation(route *Route, method string) *OpenAPIOperation {
+	op := OpenAPIOperation{}
+	if described, ok := route.MethodMeta[method][OpenAPIOperationKey{}].(OpenAPIOperation); ok {
+		op = described
+	}
+
+	if types, ok := route.MethodMeta[method][HandlerTypesKey{}].(HandlerTypes); ok {
+		describeHandlerTypes(&op, method, types)
+	}
+
+	// Path parameters come from the pattern, unless documented explicitly
+	documented := make(map[string]bool)
+	for _, p := range op.Parameters {
+		if p.In == "path" {
+			documented[p.Name] = true
+		}
+	}
+	params := []OpenAPIParameter{}
+	for _, key := range route.Path.ParamKeys {
+		if !documented[key] {
+			params = append(params, OpenAPIParameter{Name: key, In: "path", Required: true, Schema: Schema{"type": "string"}})
+		}
+	}
+	op.Parameters = append(params, op.Parameters...)
+
+	if op.RequestBody == nil && len(route.ContentTypes) > 0 && method != "GET" && method != "HEAD" {
+		op.RequestBody = &OpenAPIRequestBody{Content: make(map[string]OpenAPIMediaType)}
+		for _, contentType := range route.ContentTypes {
+			op.RequestBody.Content[contentType] = OpenAPIMediaType{}
+		}
+	}
+
+	if len(op.Responses) == 0 {
+		op.Responses = map[string]*OpenAPIResponse{"default": &OpenAPIResponse{Description: "Default response"}}
+	}
+	return &op
+}
+
+// Documents the parameters, JSON request body and responses of a handler registered with Handle, if not described
+func describeHandlerTypes(op *OpenAPIOperation, method string, types HandlerTypes) {
+	documented := make(map[string]bool)
+	for _, p := range op.Parameters {
+		documented[p.In+" "+p.Name] = true
+	}
+	properties := map[string]Schema{}
+	required := []string{}
+	for _, field := range cachedBindFields(types.Request) {
+		rules, _ := parseValidateTag(types.Request.FieldByIndex(field.index)) // Invalid tags are reported by Validate
+		schema := SchemaFor(field.typ)
+		applyValidateRules(schema, rules)
+		isRequired := hasValidateRule(rules, "required")
+		for _, source := range []string{"path", "query", "header"} {
+			if name := field.tags[source]; name != "" && !documented[source+" "+name] {
+				op.Parameters = append(op.Parameters, OpenAPIParameter{Name: name, In: source, Required: source == "path" || isRequired, Schema: schema})
+			}
+		}
+		if name := field.tags["json"]; name != "" {
+			properties[name] = schema
+			if isRequired {
+				required = append(required, name)
+			}
+		}
+	}
+
+	if op.RequestBody == nil && len(properties) > 0 && method != "GET" && method != "HEAD" {
+		body := Schema{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			body["required"] = required
+		}
+		op.RequestBody = &OpenAPIRequestBody{Required: true, Content: map[string]OpenAPIMediaType{"application/json": {Schema: body}}}
+	}
+	if len(op.Responses) == 0 {
+		op.Responses = map[string]*OpenAPIResponse{
+			"200":     {Description: "OK", Content: map[string]OpenAPIMediaType{"application/json": {Schema: SchemaFor(types.Response)}}},
+			"default": {Description: "Error", Content: map[string]OpenAPIMediaType{"application/json": {Schema: SchemaFor(reflect.TypeOf(ErrorResponse{}))}}},
+		}
+	}
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// SchemaFor describes values of the type as encoding/json encodes them, with constraints from 'validate' tags.
+// Types with their own MarshalJSON get an empty schema, recursive types are cut off at the first repetition.
+func SchemaFor(t reflect.Type) Schema {
+	return schemaFor(t, make(map[reflect.Type]bool))
+}
+
+func schemaFor(t reflect.Type, seen map[reflect.Type]bool) Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return Schema{}
+	case reflect.PointerTo(t).Implements(textMarshalerType):
+		return Schema{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "format": "byte"}
+		}
+		return Schema{"type": "array", "items": schemaFor(t.Elem(), seen)}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": schemaFor(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return Schema{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		properties := map[string]Schema{}
+		required := []string{}
+		collectSchemaProperties(t, properties, &required, seen)
+		schema := Schema{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return Schema{}
+}
+
+func collectSchemaProperties(t reflect.Type, properties map[string]Schema, required *[]string, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if sf.Anonymous && name == "" { // Promoted, as encoding/json does
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				collectSchemaProperties(embedded, properties, required, seen)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		rules, _ := parseValidateTag(sf) // Invalid tags are reported by Validate
+		schema := schemaFor(sf.Type, seen)
+		applyValidateRules(schema, rules)
+		properties[name] = schema
+		if hasValidateRule(rules, "required") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// Adds the JSON schema keywords matching the validate rules
+func applyValidateRules(schema Schema, rules []validateRule) {
+	minKey, maxKey := "minimum", "maximum"
+	switch schema["type"] {
+	case "string":
+		minKey, maxKey = "minLength", "maxLength"
+	case "array":
+		minKey, maxKey = "minItems", "maxItems"
+	case "object":
+		minKey, maxKey = "minProperties", "maxProperties"
+	}
+	for _, rule := range rules {
+		switch rule.name {
+		case "min":
+			schema[minKey] = rule.size
+		case "max":
+			schema[maxKey] = rule.size
+		case "len":
+			schema[minKey], schema[maxKey] = rule.size, rule.size
+		case "oneof":
+			enum := make([]interface{}, len(rule.options))
+			for i, option := range rule.options {
+				enum[i] = option
+				if number, err := strconv.ParseFloat(option, 64); err == nil && minKey == "minimum" {
+					enum[i] = number
+				}
+			}
+			schema["enum"] = enum
+		case "regex":
+			schema["pattern"] = rule.regex.String()
+		case "email":
+			schema["format"] = "email"
+		}
+	}
+}
+
+func hasValidateRule(rules []validateRule, name string) bool {
+	for _, rule := range rules {
+		if rule.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadOpenAPI reads an OpenAPI 3 document in JSON or YAML format, JSON if it starts with '{'.
+// YAML documents cannot use anchors, aliases or tags.
+func LoadOpenAPI(r io.Reader) (*OpenAPIDocument, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	doc := &OpenAPIDocument{}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		err = json.Unmarshal(data, doc)
+	} else {
+		err = unmarshalYaml(data, doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode OpenAPI document: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version '%s', expected 3.x", doc.OpenAPI)
+	}
+	return doc, nil
+}
+
+// PatternFromOpenAPIPath converts an OpenAPI path template to a yar pattern, e.g. '/user/{id}' to '/user/:id'
+func PatternFromOpenAPIPath(path string) (string, error) {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			parts[i] = ":" + part[1:len(part)-1]
+		} else if strings.ContainsAny(part, "{}") {
+			return "", fmt.Errorf("path parameters must span a whole path segment, path=%s", path)
+		}
+	}
+	pattern := strings.Join(parts, "/")
+	if _, err := ParsePattern(pattern); err != nil {
+		return "", err
+	}
+	return pattern, nil
+}
+
+// OpenAPIBindError lists the operations without a handler and the handlers without an operation
+type OpenAPIBindError struct {
+	Missing []string // operationIds, or 'METHOD path' for operations without one
+	Extra   []string // Handler keys not matching any operationId
+}
+
+func (e *OpenAPIBindError) Error() string {
+	msgs := []string{}
+	if len(e.Missing) > 0 {
+		msgs = append(msgs, "missing handlers for: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Extra) > 0 {
+		msgs = append(msgs, "handlers without an operation: "+strings.Join(e.Extra, ", "))
+	}
+	return "openapi binding: " + strings.Join(msgs, "; ")
+}
+
+// NotImplementedHandler is registered by RegisterOpenAPI for operations without a handler, unless overridden
+var NotImplementedHandler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+})
+
+// RegisterOpenAPI registers every operation of doc, binding operations to handlers by their operationId.
+// Operations without a handler are served by notImplemented (NotImplementedHandler if nil), so the router
+// can serve the whole contract straight away. Missing and extra handlers are reported as an *OpenAPIBindError
+// once everything has been registered. Invalid paths, and paths colliding with each other or with routes already
+// registered (e.g. '/users/me' and '/users/{id}'), are reported before registering anything.
+func (r *Router) RegisterOpenAPI(doc *OpenAPIDocument, handlers map[string]http.Handler, notImplemented http.Handler) error {
+	if notImplemented == nil {
+		notImplemented = NotImplementedHandler
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	patterns := make(map[string]string, len(doc.Paths))
+	for path := range doc.Paths {
+		pattern, err := PatternFromOpenAPIPath(path)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+		patterns[path] = pattern
+	}
+	sort.Strings(paths)
+	if err := r.checkOpenAPICollisions(doc, paths, patterns); err != nil {
+		return err
+	}
+
+	bindErr := &OpenAPIBindError{}
+	bound := make(map[string]bool)
+	for _, path := range paths {
+		ops := doc.Paths[path].Operations()
+		methods := make([]string, 0, len(ops))
+		for method := range ops {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			op := ops[method]
+			handler := handlers[op.OperationID]
+			if op.OperationID == "" || handler == nil {
+				handler = notImplemented
+				missing := op.OperationID
+				if missing == "" {
+					missing = method + " " + path
+				}
+				bindErr.Missing = append(bindErr.Missing, missing)
+			}
+			bound[op.OperationID] = true
+			r.AddHandler(method, patterns[path], handler).Describe(method, *op)
+		}
+	}
+	for operationID := range handlers {
+		if !bound[operationID] {
+			bindErr.Extra = append(bindErr.Extra, operationID)
+		}
+	}
+	sort.Strings(bindErr.Extra)
+
+	if len(bindErr.Missing) > 0 || len(bindErr.Extra) > 0 {
+		return bindErr
+	}
+	return nil
+}
+
+// Registers the operations on a copy of the router's routes, reporting collisions instead of panicking
+func (r *Router) checkOpenAPICollisions(doc *OpenAPIDocument, paths []string, patterns map[string]string) error {
+	scratch := NewRouter()
+	for _, ri := range r.Routes() {
+		for method, handler := range ri.Route.Handlers {
+			route := scratch.addHandler(ri.Host, method, ri.Pattern, handler, ri.Route.serveMux)
+			route.implicitHead = ri.Route.implicitHead
+		}
+	}
+	errs := []string{}
+	for _, path := range paths {
+		for method := range doc.Paths[path].Operations() {
+			func() {
+				defer func() {
+					if p := recover(); p != nil {
+						errs = append(errs, fmt.Sprintf("%s %s: %v", method, path, p))
+					}
+				}()
+				scratch.AddHandler(method, patterns[path], NotImplementedHandler)
+			}()
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("cannot register OpenAPI paths: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// OpenAPIHandler serves the OpenAPI document of a router as JSON, or as YAML with the 'format=yaml' query parameter.
+// The document is generated on every request so it always reflects the registered routes.
+type OpenAPIHandler struct {
+	router *Router
+	info   OpenAPIInfo
+}
+
+func NewOpenAPIHandler(router *Router, info OpenAPIInfo) *OpenAPIHandler {
+	return &OpenAPIHandler{router: router, info: info}
+}
+
+func (h *OpenAPIHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	doc := h.router.OpenAPI(h.info)
+	switch req.URL.Query().Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(doc)
+	case "yaml":
+		w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+		writeYaml(w, doc)
+	default:
+		http.Error(w, "unknown format, expected one of: json, yaml", http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,320 @@
+package yar
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPIPath(t *testing.T) {
+	assert.Equal(t, "/", OpenAPIPath(NewPath("/").Pattern))
+	assert.Equal(t, "/user/{user_id}/files/{filepath}", OpenAPIPath(NewPath("/user/:user_id/files/*filepath").Pattern))
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/user/:user_id", func(w http.ResponseWriter, r *http.Request) {}).
+		Describe("GET", OpenAPIOperation{
+			OperationID: "getUser",
+			Tags:        []string{"users"},
+			Responses:   map[string]*OpenAPIResponse{"200": &OpenAPIResponse{Description: "The user"}},
+		})
+	router.Delete("/user/:user_id", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/static/*filepath", func(w http.ResponseWriter, r *http.Request) {})
+	router.AddHandle("PURGE", "/cache", func(w http.ResponseWriter, r *http.Request) {})
+
+	// Act
+	doc := router.OpenAPI(OpenAPIInfo{Title: "Test", Version: "1.0"})
+
+	// Assert
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Equal(t, 2, len(doc.Paths)) // PURGE cannot be described
+	user := doc.Paths["/user/{user_id}"]
+	assert.Equal(t, "getUser", user.Get.OperationID)
+	assert.Equal(t, []string{"users"}, user.Get.Tags)
+	assert.Equal(t, "The user", user.Get.Responses["200"].Description)
+	assert.Equal(t, []OpenAPIParameter{
+		OpenAPIParameter{Name: "user_id", In: "path", Required: true, Schema: Schema{"type": "string"}},
+	}, user.Delete.Parameters)
+	assert.Equal(t, "Default response", user.Delete.Responses["default"].Description)
+	assert.Equal(t, "filepath", doc.Paths["/static/{filepath}"].Get.Parameters[0].Name)
+}
+
+func TestOpenAPIDocumentKeepsDocumentedPathParameters(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/user/:user_id", func(w http.ResponseWriter, r *http.Request) {}).
+		Describe("GET", OpenAPIOperation{
+			Parameters: []OpenAPIParameter{
+				{Name: "user_id", In: "path", Required: true, Description: "User id", Schema: Schema{"type": "integer"}},
+			},
+		})
+
+	// Act
+	doc := router.OpenAPI(OpenAPIInfo{Title: "Test", Version: "1.0"})
+
+	// Assert
+	params := doc.Paths["/user/{user_id}"].Get.Parameters
+	assert.Equal(t, 1, len(params))
+	assert.Equal(t, "User id", params[0].Description)
+}
+
+func TestOpenAPIDocumentRequestBodyFromConsumes(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Post("/users", func(w http.ResponseWriter, r *http.Request) {}).Consumes("application/json")
+	router.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+
+	// Act
+	doc := router.OpenAPI(OpenAPIInfo{Title: "Test", Version: "1.0"})
+
+	// Assert
+	assert.Equal(t, &OpenAPIRequestBody{Content: map[string]OpenAPIMediaType{"application/json": {}}}, doc.Paths["/users"].Post.RequestBody)
+	assert.Nil(t, doc.Paths["/users"].Get.RequestBody)
+}
+
+func TestOpenAPIDocumentIncludesHosts(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.HandleFunc("GET /items", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleFunc("GET api.example.com/items", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleFunc("POST api.example.com/items", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleFunc("GET admin.example.com/stats", func(w http.ResponseWriter, r *http.Request) {})
+
+	// Act
+	doc := router.OpenAPI(OpenAPIInfo{Title: "Test", Version: "1.0"})
+	apiDoc := router.OpenAPIHost("api.example.com", OpenAPIInfo{Title: "Test", Version: "1.0"})
+
+	// Assert
+	assert.Equal(t, 2, len(doc.Paths))
+	assert.Nil(t, doc.Paths["/items"].Get.Servers) // Documented once, for any host
+	assert.Equal(t, []OpenAPIServer{{URL: "//api.example.com"}}, doc.Paths["/items"].Post.Servers)
+	assert.Equal(t, []OpenAPIServer{{URL: "//admin.example.com"}}, doc.Paths["/stats"].Get.Servers)
+	assert.Equal(t, 1, len(apiDoc.Paths))
+	assert.NotNil(t, apiDoc.Paths["/items"].Post)
+	assert.Nil(t, apiDoc.Paths["/items"].Post.Servers)
+}
+
+func TestOpenAPIHandlerJson(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/user/:user_id", func(w http.ResponseWriter, r *http.Request) {}).
+		Describe("GET", OpenAPIOperation{Summary: "Get a user"})
+	handler := NewOpenAPIHandler(router, OpenAPIInfo{Title: "Test", Version: "1.0"})
+	r, _ := http.NewRequest("GET", "/openapi", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(w, r)
+
+	// Assert
+	doc := OpenAPIDocument{}
+	err := json.NewDecoder(w.Body).Decode(&doc)
+	assert.Nil(t, err)
+	assert.Equal(t, "Get a user", doc.Paths["/user/{user_id}"].Get.Summary)
+}
+
+func TestOpenAPIHandlerYaml(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/user/:user_id", func(w http.ResponseWriter, r *http.Request) {}).
+		Describe("GET", OpenAPIOperation{Summary: "Get a user: by id", Tags: []string{"users"}})
+	handler := NewOpenAPIHandler(router, OpenAPIInfo{Title: "Test", Version: "1.0"})
+	r, _ := http.NewRequest("GET", "/openapi?format=yaml", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(w, r)
+
+	// Assert
+	output, _ := ioutil.ReadAll(w.Result().Body)
+	assert.Equal(t, `info:
+  title: Test
+  version: "1.0"
+openapi: 3.0.3
+paths:
+  /user/{user_id}:
+    get:
+      parameters:
+        -
+          in: path
+          name: user_id
+          required: true
+          schema:
+            type: string
+      responses:
+        default:
+          description: Default response
+      summary: "Get a user: by id"
+      tags:
+        - users
+`, string(output))
+}
+
+const testOpenAPIContract = `{
+	"openapi": "3.0.3",
+	"info": {"title": "Users", "version": "1.0"},
+	"paths": {
+		"/user/{user_id}": {
+			"parameters": [{"name": "user_id", "in": "path", "required": true}],
+			"get": {"operationId": "getUser", "responses": {"200": {"description": "The user"}}},
+			"delete": {"operationId": "deleteUser", "responses": {"204": {"description": "Deleted"}}}
+		},
+		"/health": {
+			"get": {"responses": {"200": {"description": "Healthy"}}}
+		}
+	}
+}`
+
+func TestPatternFromOpenAPIPath(t *testing.T) {
+	pattern, err := PatternFromOpenAPIPath("/user/{user_id}/post/{post_id}")
+	assert.Nil(t, err)
+	assert.Equal(t, "/user/:user_id/post/:post_id", pattern)
+
+	_, err = PatternFromOpenAPIPath("/files/{name}.json")
+	assert.NotNil(t, err)
+}
+
+func TestLoadOpenAPIRejectsOtherVersions(t *testing.T) {
+	_, err := LoadOpenAPI(strings.NewReader(`{"swagger": "2.0"}`))
+	assert.NotNil(t, err)
+}
+
+func TestLoadOpenAPIYaml(t *testing.T) {
+	// Arrange
+	contract := `openapi: 3.0.3
+info:
+  title: Users
+  version: "1.0"
+paths:
+  /user/{user_id}:
+    parameters:
+      - {name: user_id, in: path, required: true}
+    get:
+      operationId: getUser
+      responses:
+        '200':
+          description: The user
+    delete:
+      operationId: deleteUser
+      responses:
+        '204': {description: Deleted}
+  /health:
+    get:
+      description: >
+        Reports whether the service
+        can take requests.
+      responses:
+        '200':
+          description: Healthy
+`
+	expected, _ := LoadOpenAPI(strings.NewReader(testOpenAPIContract))
+	expected.Paths["/health"].Get.Description = "Reports whether the service can take requests.\n"
+
+	// Act
+	doc, err := LoadOpenAPI(strings.NewReader(contract))
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, expected, doc)
+}
+
+func TestLoadOpenAPIYamlUnquotedVersions(t *testing.T) {
+	// Arrange
+	contract := `openapi: 3.1
+info:
+  title: Users
+  version: 1.0
+paths: {}
+`
+
+	// Act
+	doc, err := LoadOpenAPI(strings.NewReader(contract))
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "3.1", doc.OpenAPI)
+	assert.Equal(t, "1.0", doc.Info.Version)
+}
+
+func TestRegisterOpenAPI(t *testing.T) {
+	// Arrange
+	doc, err := LoadOpenAPI(strings.NewReader(testOpenAPIContract))
+	assert.Nil(t, err)
+	router := NewRouter()
+	router.ShouldLog = false
+	handlers := map[string]http.Handler{
+		"getUser": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("user " + GetParam(r, "user_id")))
+		}),
+		"listUsers": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	}
+
+	// Act
+	err = router.RegisterOpenAPI(doc, handlers, nil)
+
+	// Assert
+	assert.Equal(t, &OpenAPIBindError{Missing: []string{"GET /health", "deleteUser"}, Extra: []string{"listUsers"}}, err)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/user/42", nil)
+	router.ServeHTTP(w, r)
+	assert.Equal(t, "user 42", w.Body.String())
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("DELETE", "/user/42", nil)
+	router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+
+	regenerated := router.OpenAPI(doc.Info)
+	assert.Equal(t, "getUser", regenerated.Paths["/user/{user_id}"].Get.OperationID)
+}
+
+func TestRegisterOpenAPICollisions(t *testing.T) {
+	// Arrange
+	doc, _ := LoadOpenAPI(strings.NewReader(`{
+		"openapi": "3.0.3",
+		"paths": {
+			"/users/me": {"get": {"operationId": "me"}},
+			"/users/{id}": {"get": {"operationId": "getUser"}},
+			"/health": {"get": {"operationId": "health"}}
+		}
+	}`))
+	router := NewRouter()
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {})
+
+	// Act
+	var err error
+	assert.NotPanics(t, func() { err = router.RegisterOpenAPI(doc, nil, nil) })
+
+	// Assert
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "GET /health: cannot register the same path")
+	assert.Contains(t, err.Error(), "GET /users/{id}: parameter and static parts")
+	assert.Equal(t, 1, len(router.Routes())) // Nothing registered
+}
+
+func TestRegisterOpenAPICustomNotImplemented(t *testing.T) {
+	// Arrange
+	doc, _ := LoadOpenAPI(strings.NewReader(testOpenAPIContract))
+	router := NewRouter()
+	router.ShouldLog = false
+	stub := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	// Act
+	router.RegisterOpenAPI(doc, nil, stub)
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/health", nil)
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusTeapot, w.Code)
+}
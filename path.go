@@ -23,27 +23,42 @@ func (ps Params) Value(key string) string {
 	return ""
 }
 
+// Copy returns params in a newly allocated slice, e.g. to keep them after the handler returns
+func (ps Params) Copy() Params {
+	if ps == nil {
+		return nil
+	}
+	return append(make(Params, 0, len(ps)), ps...)
+}
+
 type Path struct {
 	UrlPattern string
 	ParamKeys  []string
+	Pattern    *Pattern
 }
 
+// NewPath parses a URL pattern, panicking if it is invalid. It accepts more than ParsePattern, see there.
 func NewPath(urlPattern string) *Path {
+	pattern, err := parsePattern(urlPattern, false)
+	if err != nil {
+		panic(err.Error())
+	}
 	return &Path{
 		UrlPattern: urlPattern,
-		ParamKeys:  getParamKeys(urlPattern),
+		ParamKeys:  pattern.ParamKeys(),
+		Pattern:    pattern,
 	}
 }
 
 func (p *Path) Url(params ...string) string {
 	if len(params) != len(p.ParamKeys) {
-		panic(fmt.Sprintf("parameter number mismatch for url=%s,  params=", p.UrlPattern, len(params)))
+		panic(fmt.Sprintf("parameter number mismatch for url=%s, params=%d", p.UrlPattern, len(params)))
 	}
 	var buffer bytes.Buffer
 	pattern := p.UrlPattern
 	i, j := 0, 0
 	for i < len(pattern) {
-		if !IsParam(pattern[i]) {
+		if !IsParam(pattern[i]) || (i > 0 && pattern[i-1] != '/') { // Literal inside a segment
 			buffer.WriteByte(pattern[i])
 		} else if j < len(params) {
 			buffer.WriteString(params[j])
@@ -66,34 +81,3 @@ func (p *Path) Url(params ...string) string {
 func IsParam(char byte) bool {
 	return char == '*' || char == ':'
 }
-
-func getParamKeys(urlPattern string) []string {
-
-	parts := strings.Split(urlPattern, "/")
-	keys := []string{}
-	for i, part := range parts {
-		if strings.HasPrefix(part, ":") {
-			key := part[1:]
-			mustBeValidKey(key)
-			keys = append(keys, key)
-		}
-		if strings.HasPrefix(part, "*") {
-			key := part[1:]
-			mustBeValidKey(key)
-			keys = append(keys, key)
-			if i != len(parts)-1 {
-				panic("wilcard parameter must last in the path")
-			}
-		}
-	}
-	return keys
-}
-
-func mustBeValidKey(key string) {
-	if len(key) == 0 {
-		panic("Parameters must have names")
-	}
-	if strings.Count(key, ":") > 0 || strings.Count(key, "*") > 0 {
-		panic(fmt.Sprintf("parameter key cannot contain ':' or '*', param=%s", key))
-	}
-}
@@ -0,0 +1,144 @@
+package yar
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SegmentKind tells what a single path segment of a pattern matches
+type SegmentKind int
+
+const (
+	StaticSegment   SegmentKind = iota // Matches its literal text only
+	ParamSegment                       // ':name' - matches any text up to the next '/'
+	WildcardSegment                    // '*name' - matches the rest of the path, must be last
+)
+
+func (k SegmentKind) String() string {
+	switch k {
+	case StaticSegment:
+		return "static"
+	case ParamSegment:
+		return "param"
+	case WildcardSegment:
+		return "wildcard"
+	}
+	return fmt.Sprintf("SegmentKind(%d)", int(k))
+}
+
+// Segment is the part of a pattern in between two '/' symbols
+type Segment struct {
+	Kind  SegmentKind
+	Value string // Literal text for static segments, parameter name otherwise
+}
+
+func (s Segment) String() string {
+	switch s.Kind {
+	case ParamSegment:
+		return ":" + s.Value
+	case WildcardSegment:
+		return "*" + s.Value
+	}
+	return s.Value
+}
+
+// Pattern is the parsed form of a route's URL pattern, e.g. '/user/:user_id/files/*filepath'
+type Pattern struct {
+	Raw      string
+	Segments []Segment // Ordered segments, the leading '/' is implied
+}
+
+// PatternError is returned by ParsePattern for patterns yar cannot route
+type PatternError struct {
+	Pattern string
+	Msg     string
+}
+
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("invalid pattern '%s': %s", e.Pattern, e.Msg)
+}
+
+// ParsePattern splits a URL pattern into its segments and validates them. It is stricter than the router, which
+// also accepts patterns not beginning with '/' (they match nothing), repeated parameter names and ':' or '*' inside a
+// segment, where they are literal (e.g. '/v1/items:batchGet').
+func ParsePattern(pattern string) (*Pattern, error) {
+	return parsePattern(pattern, true)
+}
+
+// Parses the pattern, the checks ParsePattern adds to the router's are only made if strict
+func parsePattern(pattern string, strict bool) (*Pattern, error) {
+	if strict && !strings.HasPrefix(pattern, "/") {
+		return nil, &PatternError{pattern, "pattern must begin with '/'"}
+	}
+
+	parts := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	p := &Pattern{
+		Raw:      pattern,
+		Segments: make([]Segment, 0, len(parts)),
+	}
+	seen := make(map[string]bool)
+	for i, part := range parts {
+		segment := Segment{Kind: StaticSegment, Value: part}
+		if len(part) > 0 && IsParam(part[0]) {
+			segment.Value = part[1:]
+			segment.Kind = ParamSegment
+			if part[0] == '*' {
+				segment.Kind = WildcardSegment
+				if i != len(parts)-1 {
+					return nil, &PatternError{pattern, "wildcard parameter must be last in the path"}
+				}
+			}
+			if err := validateParamKey(segment.Value); err != "" {
+				return nil, &PatternError{pattern, err}
+			}
+			if strict && seen[segment.Value] {
+				return nil, &PatternError{pattern, fmt.Sprintf("duplicate parameter name, param=%s", segment.Value)}
+			}
+			seen[segment.Value] = true
+		} else if strict && strings.ContainsAny(part, ":*") {
+			return nil, &PatternError{pattern, fmt.Sprintf("parameters must start a path segment, segment=%s", part)}
+		}
+		p.Segments = append(p.Segments, segment)
+	}
+	return p, nil
+}
+
+// ParamKeys returns the names of all parameters and wildcards, in order of appearance
+func (p *Pattern) ParamKeys() []string {
+	keys := []string{}
+	for _, s := range p.Segments {
+		if s.Kind != StaticSegment {
+			keys = append(keys, s.Value)
+		}
+	}
+	return keys
+}
+
+// IsStatic reports whether the pattern matches exactly one path
+func (p *Pattern) IsStatic() bool {
+	for _, s := range p.Segments {
+		if s.Kind != StaticSegment {
+			return false
+		}
+	}
+	return true
+}
+
+// String reassembles the pattern from its segments
+func (p *Pattern) String() string {
+	parts := make([]string, len(p.Segments))
+	for i, s := range p.Segments {
+		parts[i] = s.String()
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+func validateParamKey(key string) string {
+	if len(key) == 0 {
+		return "parameters must have names"
+	}
+	if strings.ContainsAny(key, ":*") {
+		return fmt.Sprintf("parameter key cannot contain ':' or '*', param=%s", key)
+	}
+	return ""
+}
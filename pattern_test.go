@@ -0,0 +1,96 @@
+package yar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePattern(t *testing.T) {
+	p, err := ParsePattern("/user/:user_id/files/*filepath")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Segment{
+		Segment{StaticSegment, "user"},
+		Segment{ParamSegment, "user_id"},
+		Segment{StaticSegment, "files"},
+		Segment{WildcardSegment, "filepath"},
+	}, p.Segments)
+	assert.Equal(t, []string{"user_id", "filepath"}, p.ParamKeys())
+	assert.False(t, p.IsStatic())
+}
+
+func TestParsePatternRoundTrips(t *testing.T) {
+	patterns := []string{
+		"/",
+		"//",
+		"/user/",
+		"/blog/:blog_id/post/:post_id",
+		"////:a///:b/:c//",
+		"/static/*filepath",
+		"/unicode日本語/:⌘",
+	}
+
+	for _, pattern := range patterns {
+		p, err := ParsePattern(pattern)
+		assert.Nil(t, err, pattern)
+		assert.Equal(t, pattern, p.String())
+	}
+}
+
+func TestParsePatternStatic(t *testing.T) {
+	p, err := ParsePattern("/")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Segment{Segment{StaticSegment, ""}}, p.Segments)
+	assert.True(t, p.IsStatic())
+	assert.Equal(t, []string{}, p.ParamKeys())
+}
+
+func TestParsePatternErrors(t *testing.T) {
+	patterns := []string{
+		"",
+		"user",
+		"/:",
+		"/*",
+		"/::invalid",
+		"/:inva*lid",
+		"/user:id",
+		"/files/*filepath/:dummy_var",
+		"/files/*filepath/",
+		"/:id/:id",
+	}
+
+	for _, pattern := range patterns {
+		p, err := ParsePattern(pattern)
+		assert.Nil(t, p, pattern)
+		if assert.NotNil(t, err, pattern) {
+			assert.Equal(t, pattern, err.(*PatternError).Pattern)
+		}
+	}
+}
+
+func TestNewPathIsLenient(t *testing.T) {
+	tcs := []struct {
+		pattern      string
+		expectedKeys []string
+		expectedUrl  string
+	}{
+		{"user/:id", []string{"id"}, "user/1"},
+		{"/foo:bar", []string{}, "/foo:bar"},
+		{"/v1/items:batchGet", []string{}, "/v1/items:batchGet"},
+		{"/a/:id/b/:id", []string{"id", "id"}, "/a/1/b/2"},
+	}
+
+	for _, tc := range tcs {
+		// Act
+		_, err := ParsePattern(tc.pattern)
+		path := NewPath(tc.pattern)
+
+		// Assert
+		assert.NotNil(t, err, tc.pattern)
+		assert.Equal(t, tc.expectedKeys, path.ParamKeys, tc.pattern)
+		params := []string{"1", "2"}[:len(tc.expectedKeys)]
+		assert.Equal(t, tc.expectedUrl, path.Url(params...), tc.pattern)
+	}
+}
@@ -0,0 +1,223 @@
+package yar
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit configures a token bucket: it holds up to Burst tokens, refilled at Rate tokens per second,
+// and every request takes one
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitResult is the state of a bucket after taking a token
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int           // Whole tokens left
+	RetryAfter time.Duration // Until a token is available, if not allowed
+	Reset      time.Duration // Until the bucket is full again
+}
+
+// RateLimitStore keeps the token buckets. Implementations backed by e.g. Redis can share them between instances.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// RateLimitKey tells which bucket a request takes a token from
+type RateLimitKey func(r *http.Request) string
+
+// KeyByIP keys requests by the client's IP, as seen in http.Request.RemoteAddr. Behind a proxy use a
+// key reading the proxy's header instead.
+func KeyByIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// KeyByHeader keys requests by a header, e.g. an API key
+func KeyByHeader(name string) RateLimitKey {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// KeyByParam keys requests by a path parameter of the matched route, e.g. 'tenant_id'
+func KeyByParam(name string) RateLimitKey {
+	return func(r *http.Request) string {
+		return GetParam(r, name)
+	}
+}
+
+// RateLimiter is a token bucket rate limiter middleware, for a route, a group or the whole router:
+//
+//	limiter := yar.NewRateLimiter(yar.RateLimit{Rate: 10, Burst: 20}, yar.KeyByParam("tenant_id"))
+//	router.Get("/tenants/:tenant_id/reports", reports).Use(limiter.Middleware)
+//
+// Responses carry the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, and rejected
+// requests get a 429 Too Many Requests with Retry-After. If the store fails the request is let through.
+type RateLimiter struct {
+	Limit RateLimit
+	Key   RateLimitKey
+	Store RateLimitStore
+
+	// Share buckets between all routes the limiter is used on, instead of keeping them per route (host and pattern)
+	Shared bool
+	// Responds to rejected requests, after the headers have been set. If not set 429 Too Many Requests is used.
+	LimitedHandler http.Handler
+	// Responds to requests the key is empty for, e.g. without the header of KeyByHeader. If not set they are
+	// let through without being limited, rather than all sharing one bucket.
+	EmptyKeyHandler http.Handler
+}
+
+// NewRateLimiter creates a rate limiter keeping its buckets in memory, keyed by KeyByIP if key is nil
+func NewRateLimiter(limit RateLimit, key RateLimitKey) *RateLimiter {
+	if limit.Rate <= 0 || limit.Burst < 1 {
+		panic("rate limit must have a positive rate and a burst of at least 1")
+	}
+	if key == nil {
+		key = KeyByIP
+	}
+	return &RateLimiter{Limit: limit, Key: key, Store: NewMemoryRateLimitStore()}
+}
+
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		key := l.Key(req)
+		if key == "" {
+			if l.EmptyKeyHandler != nil {
+				l.EmptyKeyHandler.ServeHTTP(w, req)
+			} else {
+				next.ServeHTTP(w, req)
+			}
+			return
+		}
+		if !l.Shared {
+			key = rateLimitRoute(req) + " " + key
+		}
+		result, err := l.Store.Take(req.Context(), key, l.Limit)
+		if err != nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		header := w.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(l.Limit.Burst))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.FormatInt(ceilSeconds(result.Reset), 10))
+		if result.Allowed {
+			next.ServeHTTP(w, req)
+			return
+		}
+		header.Set("Retry-After", strconv.FormatInt(ceilSeconds(result.RetryAfter), 10))
+		if l.LimitedHandler != nil {
+			l.LimitedHandler.ServeHTTP(w, req)
+		} else {
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		}
+	})
+}
+
+// The route a request's bucket belongs to, in http.ServeMux syntax with its host, so routes registered for different
+// hosts or a ServeMux pattern's directory and subtree routes are told apart and together as ServeMux would
+func rateLimitRoute(req *http.Request) string {
+	if route := GetRoute(req); route != nil {
+		return route.muxPattern
+	}
+	return req.Pattern // Set by SetPathValues and PathValuesOnly, or by http.ServeMux
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+const rateLimitShards = 64
+
+// MemoryRateLimitStore keeps token buckets in memory, sharded to reduce lock contention.
+// Full buckets are dropped from time to time, as they are no different from new ones.
+type MemoryRateLimitStore struct {
+	shards [rateLimitShards]rateLimitShard
+	now    func() time.Time
+}
+
+type rateLimitShard struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	takes   int // Since the last sweep
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	limit  RateLimit
+}
+
+const rateLimitSweepEvery = 1024
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	s := &MemoryRateLimitStore{now: time.Now}
+	for i := range s.shards {
+		s.shards[i].buckets = make(map[string]*tokenBucket)
+	}
+	return s
+}
+
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	shard := &s.shards[hash.Sum32()%rateLimitShards]
+	now := s.now()
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if shard.takes++; shard.takes >= rateLimitSweepEvery {
+		shard.sweep(now)
+	}
+	bucket := shard.buckets[key]
+	if bucket == nil {
+		bucket = &tokenBucket{tokens: float64(limit.Burst), last: now}
+		shard.buckets[key] = bucket
+	}
+	bucket.limit = limit
+	bucket.refill(now)
+
+	result := RateLimitResult{}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = rateLimitDuration((1 - bucket.tokens) / limit.Rate)
+	}
+	result.Remaining = int(bucket.tokens)
+	result.Reset = rateLimitDuration((float64(limit.Burst) - bucket.tokens) / limit.Rate)
+	return result, nil
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+		b.last = now
+	}
+}
+
+// Drops buckets that have refilled completely
+func (s *rateLimitShard) sweep(now time.Time) {
+	s.takes = 0
+	for key, bucket := range s.buckets {
+		if bucket.refill(now); bucket.tokens >= float64(bucket.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func rateLimitDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
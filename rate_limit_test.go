@@ -0,0 +1,231 @@
+package yar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func newTestRateLimiter(limit RateLimit, key RateLimitKey) (*RateLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := NewRateLimiter(limit, key)
+	limiter.Store.(*MemoryRateLimitStore).now = clock.Now
+	return limiter, clock
+}
+
+func TestMemoryRateLimitStoreTokenBucket(t *testing.T) {
+	// Arrange
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := NewMemoryRateLimitStore()
+	store.now = clock.Now
+	limit := RateLimit{Rate: 2, Burst: 3}
+	take := func() RateLimitResult {
+		result, err := store.Take(context.Background(), "key", limit)
+		assert.Nil(t, err)
+		return result
+	}
+
+	// Act & Assert
+	assert.Equal(t, RateLimitResult{Allowed: true, Remaining: 2, Reset: 500 * time.Millisecond}, take())
+	assert.Equal(t, RateLimitResult{Allowed: true, Remaining: 1, Reset: time.Second}, take())
+	assert.Equal(t, RateLimitResult{Allowed: true, Remaining: 0, Reset: 1500 * time.Millisecond}, take())
+	assert.Equal(t, RateLimitResult{Allowed: false, Remaining: 0, RetryAfter: 500 * time.Millisecond, Reset: 1500 * time.Millisecond}, take())
+
+	clock.Add(250 * time.Millisecond)
+	assert.Equal(t, RateLimitResult{Allowed: false, Remaining: 0, RetryAfter: 250 * time.Millisecond, Reset: 1250 * time.Millisecond}, take())
+
+	clock.Add(time.Hour) // Never more than Burst tokens
+	assert.Equal(t, RateLimitResult{Allowed: true, Remaining: 2, Reset: 500 * time.Millisecond}, take())
+}
+
+func TestMemoryRateLimitStoreSweepsFullBuckets(t *testing.T) {
+	// Arrange
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := NewMemoryRateLimitStore()
+	store.now = clock.Now
+	limit := RateLimit{Rate: 1, Burst: 1}
+	store.Take(context.Background(), "full", limit)
+	clock.Add(time.Second)
+	store.Take(context.Background(), "empty", limit)
+
+	// Act
+	for i := range store.shards {
+		store.shards[i].sweep(clock.Now())
+	}
+
+	// Assert
+	keys := []string{}
+	for i := range store.shards {
+		for key := range store.shards[i].buckets {
+			keys = append(keys, key)
+		}
+	}
+	assert.Equal(t, []string{"empty"}, keys)
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	// Arrange
+	limiter, clock := newTestRateLimiter(RateLimit{Rate: 1, Burst: 2}, KeyByParam("tenant_id"))
+	router := NewRouter()
+	router.Get("/tenants/:tenant_id/reports", func(w http.ResponseWriter, r *http.Request) {}).Use(limiter.Middleware)
+	get := func(tenant string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest("GET", "/tenants/"+tenant+"/reports", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	// Act
+	first, second, limited, otherTenant := get("a"), get("a"), get("a"), get("b")
+	clock.Add(time.Second)
+	refilled := get("a")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "0", limited.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", limited.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, otherTenant.Code)
+	assert.Equal(t, http.StatusOK, refilled.Code)
+}
+
+func TestRateLimiterKeysByRouteUnlessShared(t *testing.T) {
+	tcs := []struct {
+		shared         bool
+		expectedSecond int
+	}{
+		{false, http.StatusOK},
+		{true, http.StatusTooManyRequests},
+	}
+
+	for _, tc := range tcs {
+		// Arrange
+		limiter, _ := newTestRateLimiter(RateLimit{Rate: 1, Burst: 1}, KeyByHeader("X-Api-Key"))
+		limiter.Shared = tc.shared
+		router := NewRouter()
+		api := router.Group("/api").Use(limiter.Middleware)
+		api.Get("/a", func(w http.ResponseWriter, r *http.Request) {})
+		api.Get("/b", func(w http.ResponseWriter, r *http.Request) {})
+		rA, _ := http.NewRequest("GET", "/api/a", nil)
+		rB, _ := http.NewRequest("GET", "/api/b", nil)
+		rA.Header.Set("X-Api-Key", "secret")
+		rB.Header.Set("X-Api-Key", "secret")
+		wB := httptest.NewRecorder()
+
+		// Act
+		router.ServeHTTP(httptest.NewRecorder(), rA)
+		router.ServeHTTP(wB, rB)
+
+		// Assert
+		assert.Equal(t, tc.expectedSecond, wB.Code)
+	}
+}
+
+func TestRateLimiterKeysByRouteHost(t *testing.T) {
+	// Arrange
+	limiter, _ := newTestRateLimiter(RateLimit{Rate: 1, Burst: 1}, KeyByHeader("X-Api-Key"))
+	router := NewRouter()
+	router.HandleFunc("a.example.com/items", func(w http.ResponseWriter, r *http.Request) {}).Use(limiter.Middleware)
+	router.HandleFunc("b.example.com/items", func(w http.ResponseWriter, r *http.Request) {}).Use(limiter.Middleware)
+	rA, _ := http.NewRequest("GET", "http://a.example.com/items", nil)
+	rB, _ := http.NewRequest("GET", "http://b.example.com/items", nil)
+	rA.Header.Set("X-Api-Key", "secret")
+	rB.Header.Set("X-Api-Key", "secret")
+	wB := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), rA)
+	router.ServeHTTP(wB, rB)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, wB.Code)
+}
+
+func TestRateLimiterEmptyKey(t *testing.T) {
+	tcs := []struct {
+		emptyKeyHandler http.Handler
+		expectedStatus  int
+	}{
+		{nil, http.StatusOK},
+		{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusUnauthorized) }), http.StatusUnauthorized},
+	}
+
+	for _, tc := range tcs {
+		// Arrange
+		limiter, _ := newTestRateLimiter(RateLimit{Rate: 1, Burst: 1}, KeyByHeader("X-Api-Key"))
+		limiter.EmptyKeyHandler = tc.emptyKeyHandler
+		router := NewRouter()
+		router.Get("/items", func(w http.ResponseWriter, r *http.Request) {}).Use(limiter.Middleware)
+		r, _ := http.NewRequest("GET", "/items", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		router.ServeHTTP(httptest.NewRecorder(), r)
+		router.ServeHTTP(w, r)
+
+		// Assert
+		assert.Equal(t, tc.expectedStatus, w.Code)
+		assert.Equal(t, "", w.Header().Get("RateLimit-Limit"))
+	}
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("unavailable")
+}
+
+func TestRateLimiterFailsOpen(t *testing.T) {
+	// Arrange
+	limiter := NewRateLimiter(RateLimit{Rate: 1, Burst: 1}, nil)
+	limiter.Store = failingRateLimitStore{}
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	r, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", w.Header().Get("RateLimit-Limit"))
+}
+
+func TestKeyByIP(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+
+	assert.Equal(t, "10.0.0.1", KeyByIP(r))
+}
+
+func TestNewRateLimiterPanicsOnInvalidLimit(t *testing.T) {
+	assert.Panics(t, func() { NewRateLimiter(RateLimit{Rate: 0, Burst: 1}, nil) })
+	assert.Panics(t, func() { NewRateLimiter(RateLimit{Rate: 1, Burst: 0}, nil) })
+}
@@ -0,0 +1,51 @@
+package yar
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicHandler writes the response for a request whose handler panicked, see Router.PanicHandler
+type PanicHandler func(w http.ResponseWriter, req *http.Request, recovered interface{}, stack []byte)
+
+// DefaultPanicHandler responds with a plain 500 Internal Server Error, without exposing the panic
+func DefaultPanicHandler(w http.ResponseWriter, req *http.Request, recovered interface{}, stack []byte) {
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// A panic recovered in another goroutine, see Router.Timeout, raised again in the serving one with its original stack
+type goroutinePanic struct {
+	value interface{}
+	stack []byte
+}
+
+// Printed by net/http if nothing recovers it
+func (p *goroutinePanic) String() string {
+	return fmt.Sprintf("%v\n\n%s", p.value, p.stack)
+}
+
+// Serves the request with the middlewares and dispatch, recovering panics when Router.PanicHandler is set
+func (r *Router) serveRecovered(w http.ResponseWriter, req *http.Request, route *Route, params Params) {
+	rw := newResponseWriter(w)
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		if recovered == http.ErrAbortHandler { // Meant for net/http, which aborts the response quietly
+			panic(recovered)
+		}
+		stack := debug.Stack()
+		if p, ok := recovered.(*goroutinePanic); ok {
+			recovered, stack = p.value, p.stack
+		}
+		r.emitEvent(Event{Kind: EventPanic, Request: req, Route: route, Params: params, Panic: recovered, Stack: stack})
+		if rw.wroteHeader {
+			// Too late for an error response, abort it so the client does not mistake it for a complete one
+			panic(http.ErrAbortHandler)
+		}
+		r.PanicHandler(rw, req, recovered, stack)
+	}()
+	r.serve(rw.writer(), req, route, params)
+}
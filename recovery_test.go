@@ -0,0 +1,128 @@
+package yar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPanicHandlerDefault(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.PanicHandler = DefaultPanicHandler
+	router.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Partial", "1")
+		panic("boom")
+	})
+	r, _ := http.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "Internal Server Error\n", w.Body.String())
+}
+
+func TestPanicHandlerCustom(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	var recovered interface{}
+	var stack string
+	router.PanicHandler = func(w http.ResponseWriter, req *http.Request, rec interface{}, s []byte) {
+		recovered, stack = rec, string(s)
+		w.WriteHeader(http.StatusTeapot)
+	}
+	router.Get("/user/:id", func(w http.ResponseWriter, r *http.Request) {
+		panic(GetParam(r, "id"))
+	})
+	r, _ := http.NewRequest("GET", "/user/42", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, "42", recovered)
+	assert.True(t, strings.Contains(stack, "recovery_test.go"))
+}
+
+func TestPanicInMiddlewareIsRecovered(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.PanicHandler = DefaultPanicHandler
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("middleware")
+		})
+	})
+	r, _ := http.NewRequest("GET", "/missing", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestPanicAfterWriteAbortsResponse(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	called := false
+	router.PanicHandler = func(w http.ResponseWriter, req *http.Request, rec interface{}, s []byte) {
+		called = true
+	}
+	var events []Event
+	router.Logger = LoggerFunc(func(e Event) { events = append(events, e) })
+	router.Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("boom")
+	})
+	r, _ := http.NewRequest("GET", "/stream", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	var repanicked interface{}
+	func() {
+		defer func() { repanicked = recover() }()
+		router.ServeHTTP(w, r)
+	}()
+
+	// Assert
+	assert.Equal(t, http.ErrAbortHandler, repanicked)
+	assert.False(t, called)
+	assert.Equal(t, EventPanic, events[len(events)-1].Kind)
+	assert.Equal(t, "boom", events[len(events)-1].Panic)
+}
+
+func TestErrAbortHandlerIsNotRecovered(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.PanicHandler = DefaultPanicHandler
+	router.Get("/abort", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+	r, _ := http.NewRequest("GET", "/abort", nil)
+	w := httptest.NewRecorder()
+
+	// Act & Assert
+	assert.Panics(t, func() {
+		router.ServeHTTP(w, r)
+	})
+}
+
+func TestPanicWithoutPanicHandlerPropagates(t *testing.T) {
+	router := NewRouter()
+	router.Get("/panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	r, _ := http.NewRequest("GET", "/panic", nil)
+
+	assert.Panics(t, func() {
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	})
+}
@@ -0,0 +1,55 @@
+package yar
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// LimitBody sets the largest request body the route accepts, for all of its methods. Requests declaring a larger
+// Content-Length are answered with 413 Request Entity Too Large before the handler runs; for other requests the body
+// is wrapped with http.MaxBytesReader, so reading past the limit fails with an *http.MaxBytesError.
+func (rt *Route) LimitBody(maxBytes int64) *Route {
+	rt.MaxBodySize = maxBytes
+	return rt
+}
+
+// Consumes sets the accepted request body content types for all of the route's methods, e.g. 'application/json' or 'image/*'.
+// Requests with a body of any other type are answered with 415 Unsupported Media Type before the handler runs.
+func (rt *Route) Consumes(contentTypes ...string) *Route {
+	rt.ContentTypes = append(rt.ContentTypes, contentTypes...)
+	return rt
+}
+
+// Checks the request body against the route's limits, answering 413 or 415 if it does not pass.
+// It returns the request to pass on, with its body limited, or nil if it has been answered.
+func (r *Router) checkRequestBody(w http.ResponseWriter, req *http.Request, route *Route) *http.Request {
+	if len(route.ContentTypes) > 0 && req.ContentLength != 0 && !matchesContentType(route.ContentTypes, req.Header.Get("Content-Type")) {
+		http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+		return nil
+	}
+	if route.MaxBodySize > 0 && req.Body != nil && req.Body != http.NoBody {
+		if req.ContentLength > route.MaxBodySize {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return nil
+		}
+		limited := req.WithContext(req.Context()) // The request may be the caller's, so don't change it
+		limited.Body = http.MaxBytesReader(w, req.Body, route.MaxBodySize)
+		return limited
+	}
+	return req
+}
+
+func matchesContentType(accepted []string, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, a := range accepted {
+		a = strings.ToLower(a)
+		if a == mediaType || a == "*/*" || (strings.HasSuffix(a, "/*") && strings.HasPrefix(mediaType, a[:len(a)-1])) {
+			return true
+		}
+	}
+	return false
+}
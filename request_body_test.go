@@ -0,0 +1,83 @@
+package yar
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitBody(t *testing.T) {
+	// Arrange
+	var readErr error
+	router := NewRouter()
+	router.Post("/upload", func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}).LimitBody(5)
+
+	tcs := []struct {
+		body          string
+		contentLength int64
+		expectedCode  int
+		expectedErr   bool
+	}{
+		{"12345", 5, http.StatusOK, false},
+		{"123456", 6, http.StatusRequestEntityTooLarge, false},
+		{"123456", -1, http.StatusOK, true}, // Unknown length, only noticed by the handler
+		{"", 0, http.StatusOK, false},
+	}
+
+	for _, tc := range tcs {
+		// Act
+		readErr = nil
+		r, _ := http.NewRequest("POST", "/upload", strings.NewReader(tc.body))
+		r.ContentLength = tc.contentLength
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		// Assert
+		assert.Equal(t, tc.expectedCode, w.Code, tc.body)
+		var maxBytesErr *http.MaxBytesError
+		assert.Equal(t, tc.expectedErr, errors.As(readErr, &maxBytesErr), tc.body)
+	}
+}
+
+func TestConsumes(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Post("/users", func(w http.ResponseWriter, r *http.Request) {}).Consumes("application/json")
+	router.Put("/avatar", func(w http.ResponseWriter, r *http.Request) {}).Consumes("image/*")
+	router.Delete("/users", func(w http.ResponseWriter, r *http.Request) {})
+
+	tcs := []struct {
+		method, path, contentType, body string
+		expected                        int
+	}{
+		{"POST", "/users", "application/json", "{}", http.StatusOK},
+		{"POST", "/users", "Application/JSON; charset=utf-8", "{}", http.StatusOK},
+		{"POST", "/users", "text/plain", "{}", http.StatusUnsupportedMediaType},
+		{"POST", "/users", "", "{}", http.StatusUnsupportedMediaType},
+		{"POST", "/users", "", "", http.StatusOK}, // No body to check
+		{"PUT", "/avatar", "image/png", "png", http.StatusOK},
+		{"PUT", "/avatar", "text/png", "png", http.StatusUnsupportedMediaType},
+		{"DELETE", "/users", "text/plain", "x", http.StatusUnsupportedMediaType}, // Applies to all of the route's methods
+		{"DELETE", "/users", "", "", http.StatusOK},
+	}
+
+	for _, tc := range tcs {
+		// Act
+		r, _ := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		if tc.contentType != "" {
+			r.Header.Set("Content-Type", tc.contentType)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		// Assert
+		assert.Equal(t, tc.expected, w.Code, tc.method+" "+tc.path+" "+tc.contentType)
+	}
+}
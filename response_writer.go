@@ -0,0 +1,117 @@
+package yar
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// responseWriter records the status code and number of bytes written through it.
+// Hand writer() to the next handler, so http.Flusher, http.Hijacker and io.ReaderFrom keep working
+// when, and only when, the wrapped writer supports them.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = status >= 200 // Informational headers can be followed by another one
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Unwrap allows http.ResponseController to reach the wrapped writer
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Each adds one of the optional interfaces, see writer
+type (
+	responseFlusher    struct{ *responseWriter }
+	responseHijacker   struct{ *responseWriter }
+	responseReaderFrom struct{ *responseWriter }
+)
+
+func (w responseFlusher) Flush() {
+	w.wroteHeader = true
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w responseHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w responseReaderFrom) ReadFrom(src io.Reader) (int64, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+	w.size += n
+	return n, err
+}
+
+// writer returns w implementing the optional interfaces of the wrapped writer
+func (w *responseWriter) writer() http.ResponseWriter {
+	_, flusher := w.ResponseWriter.(http.Flusher)
+	_, hijacker := w.ResponseWriter.(http.Hijacker)
+	_, readerFrom := w.ResponseWriter.(io.ReaderFrom)
+	f, h, r := responseFlusher{w}, responseHijacker{w}, responseReaderFrom{w}
+	switch {
+	case flusher && hijacker && readerFrom:
+		return struct {
+			*responseWriter
+			responseFlusher
+			responseHijacker
+			responseReaderFrom
+		}{w, f, h, r}
+	case flusher && hijacker:
+		return struct {
+			*responseWriter
+			responseFlusher
+			responseHijacker
+		}{w, f, h}
+	case flusher && readerFrom:
+		return struct {
+			*responseWriter
+			responseFlusher
+			responseReaderFrom
+		}{w, f, r}
+	case hijacker && readerFrom:
+		return struct {
+			*responseWriter
+			responseHijacker
+			responseReaderFrom
+		}{w, h, r}
+	case flusher:
+		return struct {
+			*responseWriter
+			responseFlusher
+		}{w, f}
+	case hijacker:
+		return struct {
+			*responseWriter
+			responseHijacker
+		}{w, h}
+	case readerFrom:
+		return struct {
+			*responseWriter
+			responseReaderFrom
+		}{w, r}
+	}
+	return w
+}
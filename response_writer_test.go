@@ -0,0 +1,69 @@
+package yar
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (h *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+
+func TestResponseWriterRecordsStatusAndSize(t *testing.T) {
+	rw := newResponseWriter(httptest.NewRecorder())
+
+	rw.WriteHeader(http.StatusNotFound)
+	rw.WriteHeader(http.StatusOK) // Ignored, like net/http does
+	rw.Write([]byte("abc"))
+	io.WriteString(rw, "de")
+
+	assert.Equal(t, http.StatusNotFound, rw.status)
+	assert.Equal(t, int64(5), rw.size)
+}
+
+func TestResponseWriterPreservesInterfaces(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	underlying := &hijackableRecorder{ResponseRecorder: recorder}
+	rw := newResponseWriter(struct {
+		*hijackableRecorder
+		io.ReaderFrom
+	}{underlying, recorder.Body})
+	w := rw.writer()
+
+	w.(http.Flusher).Flush()
+	_, _, err := w.(http.Hijacker).Hijack()
+	n, _ := w.(io.ReaderFrom).ReadFrom(strings.NewReader("streamed"))
+
+	assert.True(t, recorder.Flushed)
+	assert.Nil(t, err)
+	assert.True(t, underlying.hijacked)
+	assert.Equal(t, int64(8), n)
+	assert.Equal(t, int64(8), rw.size)
+	assert.Equal(t, "streamed", recorder.Body.String())
+	assert.Equal(t, recorder, http.ResponseWriter(newResponseWriter(recorder).Unwrap()))
+}
+
+func TestResponseWriterAdvertisesOnlySupportedInterfaces(t *testing.T) {
+	w := newResponseWriter(httptest.NewRecorder()).writer()
+
+	_, flusher := w.(http.Flusher)
+	_, hijacker := w.(http.Hijacker)
+	_, readerFrom := w.(io.ReaderFrom)
+
+	assert.True(t, flusher)
+	assert.False(t, hijacker)
+	assert.False(t, readerFrom)
+}
@@ -6,7 +6,8 @@ type node struct {
 	char      byte
 	route     *Route // Only leaf nodes have a route != nil
 	paramKey  string
-	maxParams int // Maximum number of params that would need to be allocated for any path in this node's subtree
+	maxParams int  // Maximum number of params that would need to be allocated for any path in this node's subtree
+	yields    bool // Parameter or wildcard of a ServeMux pattern, it can be next to static parts and is tried after them
 	parent    *node
 	children  []*node
 }
@@ -15,9 +16,20 @@ func (n *node) AddChild(c *node) {
 	n.children = append(n.children, c)
 }
 
+// GetChild returns the static child for b, ':' and '*' are literal there
 func (n *node) GetChild(b byte) *node {
 	for _, c := range n.children {
-		if c.char == b {
+		if c.char == b && c.paramKey == "" {
+			return c
+		}
+	}
+	return nil
+}
+
+// Returns the parameter (':') or wildcard ('*') child
+func (n *node) paramChild(kind byte) *node {
+	for _, c := range n.children {
+		if c.char == kind && c.paramKey != "" {
 			return c
 		}
 	}
@@ -25,7 +37,8 @@ func (n *node) GetChild(b byte) *node {
 }
 
 type routeTrie struct {
-	root node
+	root       node
+	backtracks bool // Has nodes that yield, so lookups may need to backtrack
 }
 
 func newRouteTrie() *routeTrie {
@@ -38,25 +51,33 @@ func (rt *routeTrie) AddRoute(route *Route) {
 	paramCnt := 0
 	maxParams := max(current.maxParams, len(route.Path.ParamKeys))
 	for i := 0; i < len(pattern); i++ {
-		next := current.GetChild(pattern[i])
-		// Extract parameter if it exists, will be empty otherwise
+		// Extract parameter if one starts a path segment here, will be empty otherwise
 		char := pattern[i]
 		paramKey := ""
-		if isParameter(pattern[i]) {
-			char = pattern[i]
+		if isParameter(char) && (i == 0 || pattern[i-1] == '/') {
 			paramKey = prefixUntilSlash(pattern[i+1:])
 			i += len(paramKey) // Advance to next path part
 			paramCnt++
 		}
+		next := current.GetChild(char)
+		if paramKey != "" {
+			next = current.paramChild(char)
+		}
 		// If no next node exists create one
 		if next == nil {
-			mustNotCollide(current, char, paramKey)
+			mustNotCollide(current, char, paramKey, route.serveMux)
 			next = &node{
 				char:     char,
 				parent:   current,
 				paramKey: paramKey,
 			}
 			current.AddChild(next)
+		} else if paramKey != "" && next.paramKey != paramKey {
+			panic("cannot have two different parameter names for the same path part, e.g.: [/user/:user_id,/user/:user]")
+		}
+		if paramKey != "" && route.serveMux {
+			next.yields = true
+			rt.backtracks = true
 		}
 		// Add route if this is a leaf node
 		if i == len(pattern)-1 {
@@ -69,20 +90,23 @@ func (rt *routeTrie) AddRoute(route *Route) {
 	}
 }
 
-// Ensuring there is no path collision
-func mustNotCollide(node *node, char byte, paramKey string) {
-	if isParameter(char) {
-		if (node.GetChild(':') != nil && char == '*') ||
-			(node.GetChild('*') != nil && char == ':') {
-			panic("parameter and wilcard types cannot be in the same path part, e.g.:[/user/:user_id,/user/*user_id]")
-		} else if node.GetChild(char) != nil && node.GetChild(char).paramKey != paramKey {
-			panic("cannot have two different parameter names for the same path part, e.g.: [/user/:user_id,/user/:user]")
-		} else if len(node.children) > 0 {
-			panic("parameter and static parts of the path cannot be in the same place, e.g.: [/blog/:blog_id,/blog/new]")
-		}
-	} else if node.GetChild(':') != nil || node.GetChild('*') != nil {
+// Ensuring there is no path collision, parameters and wildcards that yield (see node.yields) can be next to static parts
+// and a wildcard that yields can be next to a parameter
+func mustNotCollide(node *node, char byte, paramKey string, yields bool) {
+	param, wildcard := node.paramChild(':'), node.paramChild('*')
+	hasStatic := false
+	for _, c := range node.children {
+		hasStatic = hasStatic || c.paramKey == ""
+	}
+	isParam := paramKey != ""
+	switch {
+	case isParam && node.paramChild(char) != nil && node.paramChild(char).paramKey != paramKey:
+		panic("cannot have two different parameter names for the same path part, e.g.: [/user/:user_id,/user/:user]")
+	case isParam && ((char == '*' && param != nil && !yields) || (char == ':' && wildcard != nil && !wildcard.yields)):
+		panic("parameter and wilcard types cannot be in the same path part, e.g.:[/user/:user_id,/user/*user_id]")
+	case (isParam && hasStatic && !yields) ||
+		(!isParam && ((param != nil && !param.yields) || (wildcard != nil && !wildcard.yields))):
 		panic("parameter and static parts of the path cannot be in the same place, e.g.: [/blog/:blog_id,/blog/new]")
-
 	}
 }
 
@@ -97,47 +121,98 @@ func isParameter(char byte) bool {
 }
 
 func (rt *routeTrie) FindRoute(path string) (*Route, Params) {
-	var params Params
-	paramCnt := 0
+	return rt.findRoute(path, nil)
+}
+
+// Same as FindRoute, but appends found parameters to params, which is only allocated if nil and needed
+func (rt *routeTrie) findRoute(path string, params Params) (*Route, Params) {
+	if rt.backtracks {
+		route, found := findRouteBacktracking(&rt.root, path, params)
+		if route == nil {
+			return nil, found[:0]
+		}
+		return route, found
+	}
 	current := &rt.root
 	for i := 0; i < len(path); i++ {
-		var next *node
 		// Static part
-		if !isParameter(path[i]) {
-			next = current.GetChild(path[i])
-		}
+		next := current.GetChild(path[i])
 		// If there is no static part, check for parameters
 		if next == nil {
-			if current.GetChild(':') != nil {
-				next = current.GetChild(':')
+			if current.paramChild(':') != nil {
+				next = current.paramChild(':')
 				paramVal := prefixUntilSlash(path[i:])
 				if params == nil { // Lazy init
-					params = make(Params, next.maxParams)
+					params = make(Params, 0, next.maxParams)
 				}
-				params[paramCnt] = Param{Key: next.paramKey, Value: paramVal}
-				paramCnt++
+				params = append(params, Param{Key: next.paramKey, Value: paramVal})
 				i += len(paramVal) - 1 // Advance to next path part
-			} else if current.GetChild('*') != nil {
-				next = current.GetChild('*')
+			} else if current.paramChild('*') != nil {
+				next = current.paramChild('*')
 				paramVal := path[i:]
 				if params == nil { // Lazy init
-					params = make(Params, next.maxParams)
+					params = make(Params, 0, next.maxParams)
 				}
-				params[paramCnt] = Param{Key: next.paramKey, Value: paramVal}
-				paramCnt++
-				return current.GetChild('*').route, params[:paramCnt] // If wildcard we return immediately
+				params = append(params, Param{Key: next.paramKey, Value: paramVal})
+				return next.route, params // If wildcard we return immediately
 			}
 		}
 		// Unrecognized path
 		if next == nil {
-			return nil, nil
+			return nil, params[:0]
 		}
 		current = next
 	}
 	if current != nil && current.route != nil { // Found a leaf node
-		return current.route, params[:paramCnt]
+		return current.route, params
+	}
+	return nil, params[:0] // Unrecognized path
+}
+
+// Matches path below n like findRoute, but when the static parts lead nowhere it tries the parameter and then the
+// wildcard, so the most specific pattern wins
+func findRouteBacktracking(n *node, path string, params Params) (*Route, Params) {
+	if path == "" {
+		return n.route, params
+	}
+	if next := n.GetChild(path[0]); next != nil {
+		if route, found := findRouteBacktracking(next, path[1:], params); route != nil {
+			return route, found
+		}
+	}
+	if next := n.paramChild(':'); next != nil {
+		paramVal := prefixUntilSlash(path)
+		if params == nil { // Lazy init
+			params = make(Params, 0, next.maxParams)
+		}
+		found := append(params, Param{Key: next.paramKey, Value: paramVal})
+		if route, found := findRouteBacktracking(next, path[len(paramVal):], found); route != nil {
+			return route, found
+		}
+	}
+	if next := n.paramChild('*'); next != nil && next.route != nil {
+		if params == nil { // Lazy init
+			params = make(Params, 0, next.maxParams)
+		}
+		return next.route, append(params, Param{Key: next.paramKey, Value: path})
+	}
+	return nil, params
+}
+
+// Routes returns every route stored in the trie, in depth-first order
+func (rt *routeTrie) Routes() []*Route {
+	routes := []*Route{}
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n.route != nil {
+			routes = append(routes, n.route)
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
 	}
-	return nil, nil // Unrecognized path
+	walk(&rt.root)
+	return routes
 }
 
 func prefixUntilSlash(str string) string {
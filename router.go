@@ -3,86 +3,291 @@ package yar
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
-// Used to store parameters in http.Request.Context
+// Used to store the matched route and its parameters in http.Request.Context
 type requestContextKey int
 
 const ROUTE_PARAMS_KEY requestContextKey = 0
 
+// Wraps http.Request.Context and answers for ROUTE_PARAMS_KEY itself,
+// saving the allocation of a separate context.WithValue
+type routeContext struct {
+	context.Context
+	router   *Router
+	route    *Route
+	params   Params
+	detached bool // Still in use by a handler that timed out, not returned to the pool
+}
+
+func (rc *routeContext) Value(key interface{}) interface{} {
+	if key == ROUTE_PARAMS_KEY {
+		return rc
+	}
+	return rc.Context.Value(key)
+}
+
+// Used by routers with PoolContexts set
+var routeContextPool = sync.Pool{
+	New: func() interface{} {
+		return &routeContext{}
+	},
+}
+
+func (rc *routeContext) release() {
+	for i := range rc.params {
+		rc.params[i] = Param{} // Don't keep the strings alive while pooled
+	}
+	rc.params = rc.params[:0]
+	rc.Context = nil
+	rc.router = nil
+	rc.route = nil
+	routeContextPool.Put(rc)
+}
+
+// AnyMethod registers a handler for all methods without a handler of their own
+const AnyMethod = "*"
+
 type Route struct {
-	Path     *Path
-	Handlers map[string]http.Handler // Method handlers
+	Path       *Path
+	Host       string                  // Only set for routes registered with a host, see Router.Handle
+	Handlers   map[string]http.Handler // Method handlers, AnyMethod handles methods without their own
+	Name       string                  // Optional, used for introspection
+	Meta       Metadata                // Route wide metadata
+	MethodMeta map[string]Metadata     // Method specific metadata, takes precedence over Meta
+
+	MaxBodySize  int64    // Largest accepted request body in bytes, 0 for no limit, see LimitBody
+	ContentTypes []string // Accepted request body content types, any if empty, see Consumes
+
+	middlewares  []Middleware            // See Route.Use
+	chains       map[string]http.Handler // Handlers wrapped in middlewares, nil if there are none
+	serveMux     bool                    // Registered with an http.ServeMux pattern, see Router.Handle
+	implicitHead bool                    // The HEAD handler is the GET one of a ServeMux pattern, it can be replaced
+	muxPattern   string                  // The pattern in http.ServeMux syntax, set as http.Request.Pattern
+	config       *routeConfigState       // What ApplyRouteConfig added, nil for routes registered in code only
 }
 
 func NewRoute(urlPattern string) *Route {
 	return &Route{
-		Path:     NewPath(urlPattern),
-		Handlers: make(map[string]http.Handler),
+		Path:       NewPath(urlPattern),
+		Handlers:   make(map[string]http.Handler),
+		Meta:       make(Metadata),
+		MethodMeta: make(map[string]Metadata),
 	}
 }
 
+// Named sets the route's name, returning the route so calls can be chained after registration
+func (rt *Route) Named(name string) *Route {
+	rt.Name = name
+	return rt
+}
+
+// Methods returns the sorted list of methods with a registered handler
+func (rt *Route) Methods() []string {
+	methods := make([]string, 0, len(rt.Handlers))
+	for method := range rt.Handlers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// RouteInfo describes a registered route, as returned by Router.Routes
+type RouteInfo struct {
+	Host    string // Empty for routes matching any host
+	Pattern string
+	Methods []string // Sorted
+	Name    string
+	Meta    Metadata
+	Route   *Route
+}
+
+// WalkFunc is called by Router.Walk for each route, returning an error stops the walk
+type WalkFunc func(info RouteInfo) error
+
 type Router struct {
 	NotFoundHandler         http.Handler // If not set the default handler is used
 	MethodNotAllowedHandler http.Handler // If not set the default handler is used
+	TimeoutHandler          http.Handler // Responds when a handler times out, see Timeout. If not set 503 Service Unavailable is used
 	ShouldHandleOptions     bool         // Print allowed methods for a resource/route
-	ShouldLog               bool         // Used to help with debugging
-	routeTrie               routeTrie
+	Logger                  Logger       // Receives routing events, if not set nothing is logged
+	Tracer                  Tracer       // Starts a span around each matched method handler, if set
+
+	// Recovers panics from handlers and middlewares, e.g. DefaultPanicHandler. It is only called if nothing has
+	// been written yet, otherwise the response is aborted. If not set, panics are left to net/http.
+	PanicHandler PanicHandler
+
+	// Responds when binding or validation fails in BindValid and Handle, DefaultValidationErrorHandler if not set
+	ValidationErrorHandler func(w http.ResponseWriter, req *http.Request, err error)
+
+	// Responds to errors returned by functions registered with Handle, DefaultErrorHandler if not set
+	ErrorHandler func(w http.ResponseWriter, req *http.Request, err error)
+
+	// Reuse the request's route context and Params once the router's ServeHTTP returns, making
+	// parameterized requests allocate only the shallow request copy of http.Request.WithContext.
+	// Handlers and middlewares must then not use the request's context, GetParams or GetRoute after
+	// they return, e.g. from a goroutine; copy what is needed first (see Params.Copy).
+	PoolContexts bool
+
+	// Also set every parameter with http.Request.SetPathValue, and http.Request.Pattern to the route's
+	// pattern in http.ServeMux syntax (e.g. '/user/{id}'), so handlers written for http.ServeMux can read them
+	SetPathValues bool
+
+	// Like SetPathValues, but the route context is not added to the request, saving its allocation unless router
+	// middlewares need it. GetParam still works through r.PathValue; GetParams, GetRoute and the router's
+	// ValidationErrorHandler and ErrorHandler are not available to handlers.
+	PathValuesOnly bool
+
+	// Deprecated: set Logger instead. If true and Logger is not set, events are logged to slog.Default().
+	ShouldLog bool
+
+	table       atomic.Pointer[routeTable] // Swapped as a whole when applying a RouteConfig
+	middlewares []Middleware
+	handler     http.Handler  // Middlewares wrapped around dispatch, nil if there are none
+	spas        []*spaHandler // Serve requests no route matches, see ServeSPA
 }
 
-func NewRouter() *Router {
-	return &Router{
-		routeTrie: *newRouteTrie(),
-		ShouldLog: true,
+// All registered routes
+type routeTable struct {
+	trie  routeTrie
+	hosts map[string]*routeTrie // Routes registered with a host, checked before trie
+}
+
+// All routes of the table, unordered
+func (t *routeTable) routes() []*Route {
+	routes := t.trie.Routes()
+	for _, hostTrie := range t.hosts {
+		routes = append(routes, hostTrie.Routes()...)
+	}
+	return routes
+}
+
+// The trie of routes registered with host, created if there is none yet
+func (t *routeTable) trieFor(host string) *routeTrie {
+	if host == "" {
+		return &t.trie
+	}
+	if t.hosts == nil {
+		t.hosts = make(map[string]*routeTrie)
+	}
+	if t.hosts[host] == nil {
+		t.hosts[host] = newRouteTrie()
 	}
+	return t.hosts[host]
+}
+
+func NewRouter() *Router {
+	r := &Router{}
+	r.table.Store(&routeTable{trie: *newRouteTrie()})
+	return r
 }
 
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	route, params := r.routeTrie.FindRoute(req.URL.Path)
+	var rc *routeContext
+	var route *Route
+	var params Params
+	table := r.table.Load()
+	var hostTrie *routeTrie
+	if table.hosts != nil {
+		hostTrie = table.hosts[stripPort(req.Host)]
+	}
+	if r.PoolContexts && (table.trie.root.maxParams > 0 || hostTrie != nil && hostTrie.root.maxParams > 0) {
+		rc = routeContextPool.Get().(*routeContext) // Its buffer receives the parameters
+		params = rc.params
+	}
+	if hostTrie != nil {
+		route, params = hostTrie.findRoute(req.URL.Path, params)
+	}
+	if route == nil {
+		route, params = table.trie.findRoute(req.URL.Path, params)
+	}
+
 	reqWithParams := req
-	if len(params) != 0 { // Store params to context, if any
-		reqWithParams = req.WithContext(context.WithValue(req.Context(), ROUTE_PARAMS_KEY, params))
+	if r.PathValuesOnly && r.handler == nil {
+		if route != nil {
+			reqWithParams = req.WithContext(req.Context()) // A shallow copy, the caller's request is left as it was
+			setPathValues(reqWithParams, route, params)
+		}
+	} else if route != nil || r.handler != nil { // Middlewares need it even if nothing matched
+		if rc == nil && r.PoolContexts {
+			rc = routeContextPool.Get().(*routeContext)
+		} else if rc == nil {
+			rc = &routeContext{}
+		}
+		rc.Context, rc.router, rc.route, rc.params = req.Context(), r, route, params
+		reqWithParams = req.WithContext(rc)
+		if (r.SetPathValues || r.PathValuesOnly) && route != nil {
+			setPathValues(reqWithParams, route, params)
+		}
+	}
+
+	if r.PanicHandler != nil {
+		r.serveRecovered(w, reqWithParams, route, params)
+	} else {
+		r.serve(w, reqWithParams, route, params)
 	}
 
+	if r.PoolContexts && rc != nil && !rc.detached { // Not deferred, a panicking handler might still be using it
+		rc.params = params // Keep a grown buffer
+		rc.release()
+	}
+}
+
+func setPathValues(req *http.Request, route *Route, params Params) {
+	for _, p := range params {
+		req.SetPathValue(p.Key, p.Value)
+	}
+	req.Pattern = route.muxPattern
+}
+
+func (r *Router) serve(w http.ResponseWriter, req *http.Request, route *Route, params Params) {
+	if r.handler != nil {
+		r.handler.ServeHTTP(w, req)
+	} else {
+		r.dispatch(w, req, route, params)
+	}
+}
+
+// Calls the method handler for a matched route, or one of the router's own handlers
+func (r *Router) dispatch(w http.ResponseWriter, req *http.Request, route *Route, params Params) {
 	if route != nil { // Found route
-		handler := route.Handlers[req.Method]
-		if handler != nil { // Found method handler
-			if r.ShouldLog {
-				log.Printf("[YAR] [%s] [%s] -> [Found: %s]", req.Method, req.URL, route.Path.UrlPattern)
+		if handler := route.handler(req.Method); handler != nil { // Found method handler
+			r.logEvent(EventMatch, req, route, params)
+			if route.MaxBodySize > 0 || len(route.ContentTypes) > 0 {
+				if req = r.checkRequestBody(w, req, route); req == nil {
+					return
+				}
+			}
+			if r.Tracer != nil {
+				r.serveTraced(w, req, route, handler)
+			} else {
+				handler.ServeHTTP(w, req)
 			}
-			handler.ServeHTTP(w, reqWithParams)
 		} else if req.Method == "OPTIONS" && r.ShouldHandleOptions {
-			r.handleOptions(w, reqWithParams, route)
+			r.handleOptions(w, req, route, params)
 		} else {
-			r.handleMethodNotAllowed(w, reqWithParams)
+			r.handleMethodNotAllowed(w, req, route, params)
 		}
+	} else if spa := r.findSPA(req); spa != nil {
+		spa.ServeHTTP(w, req)
 	} else {
-		r.handleNotFound(w, reqWithParams)
-
+		r.handleNotFound(w, req)
 	}
 }
 
-func (r *Router) handleOptions(w http.ResponseWriter, req *http.Request, route *Route) {
-	if r.ShouldLog {
-		log.Printf("[YAR] [%s] [%s] -> [Handling OPTIONS]", req.Method, req.URL)
-	}
+func (r *Router) handleOptions(w http.ResponseWriter, req *http.Request, route *Route, params Params) {
+	r.logEvent(EventOptions, req, route, params)
 
-	methods := make([]string, 0)
-	for method := range route.Handlers {
-		methods = append(methods, method)
-	}
-	sort.Strings(methods)
-	w.Write([]byte("Allowed: " + strings.Join(methods, ", ") + "\n"))
+	w.Write([]byte("Allowed: " + strings.Join(route.Methods(), ", ") + "\n"))
 }
 
-func (r *Router) handleMethodNotAllowed(w http.ResponseWriter, req *http.Request) {
-	if r.ShouldLog {
-		log.Printf("[YAR] [%s] [%s] -> [Method Not Allowed]", req.Method, req.URL)
-	}
+func (r *Router) handleMethodNotAllowed(w http.ResponseWriter, req *http.Request, route *Route, params Params) {
+	r.logEvent(EventMethodNotAllowed, req, route, params)
 
 	if r.MethodNotAllowedHandler != nil {
 		r.MethodNotAllowedHandler.ServeHTTP(w, req)
@@ -92,9 +297,7 @@ func (r *Router) handleMethodNotAllowed(w http.ResponseWriter, req *http.Request
 }
 
 func (r *Router) handleNotFound(w http.ResponseWriter, req *http.Request) {
-	if r.ShouldLog {
-		log.Printf("[YAR] [%s] [%s] -> [Not Found]", req.Method, req.URL)
-	}
+	r.logEvent(EventNotFound, req, nil, nil)
 
 	if r.NotFoundHandler != nil {
 		r.NotFoundHandler.ServeHTTP(w, req)
@@ -103,64 +306,155 @@ func (r *Router) handleNotFound(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
-func (r *Router) AddHandler(method, path string, handler http.Handler) {
-	route, _ := r.routeTrie.FindRoute(path)
-	// If route doesn't exist, first create it
-	if route == nil {
+func (r *Router) AddHandler(method, path string, handler http.Handler) *Route {
+	return r.addHandler("", method, path, handler, false)
+}
+
+// Registers the handler, serveMux routes follow ServeMux precedence (see Router.Handle)
+func (r *Router) addHandler(host, method, path string, handler http.Handler, serveMux bool) *Route {
+	trie := r.table.Load().trieFor(host)
+	route, _ := trie.FindRoute(path)
+	// If route doesn't exist, first create it (a different pattern matching the path will collide)
+	if route == nil || route.Path.UrlPattern != path {
 		route = NewRoute(path)
-		r.routeTrie.AddRoute(route)
+		route.Host = host
+		route.serveMux = serveMux
+		route.muxPattern = host + serveMuxPath(route.Path.Pattern, serveMux)
+		trie.AddRoute(route)
 	}
 	// Add method handler
-	if route.Handlers[method] != nil {
+	if route.Handlers[method] != nil && !(method == "HEAD" && route.implicitHead) {
 		panic(fmt.Sprintf("cannot register the same path ('%s') and method ('%s') more than once", path, method))
 	}
+	if method == "HEAD" {
+		route.implicitHead = false
+	}
 	route.Handlers[method] = handler
+	if serveMux && method == "GET" && route.Handlers["HEAD"] == nil { // ServeMux's GET patterns match HEAD too
+		route.Handlers["HEAD"] = handler
+		route.implicitHead = true
+	}
+	route.buildChains()
+	return route
+}
+
+func (r *Router) AddHandleFunc(method, path string, handlerFunc http.HandlerFunc) *Route {
+	return r.AddHandler(method, path, handlerFunc)
 }
 
-func (r *Router) AddHandleFunc(method, path string, handlerFunc http.HandlerFunc) {
-	r.AddHandler(method, path, handlerFunc)
+func (r *Router) AddHandle(method, path string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return r.AddHandler(method, path, http.HandlerFunc(handlerFunc))
 }
 
-func (r *Router) AddHandle(method, path string, handlerFunc func(http.ResponseWriter, *http.Request)) {
-	r.AddHandler(method, path, http.HandlerFunc(handlerFunc))
+func (r *Router) Head(path string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return r.AddHandle("HEAD", path, handlerFunc)
 }
 
-func (r *Router) Head(path string, handlerFunc func(http.ResponseWriter, *http.Request)) {
-	r.AddHandle("HEAD", path, handlerFunc)
+func (r *Router) Get(path string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return r.AddHandle("GET", path, handlerFunc)
 }
 
-func (r *Router) Get(path string, handlerFunc func(http.ResponseWriter, *http.Request)) {
-	r.AddHandle("GET", path, handlerFunc)
+func (r *Router) Post(path string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return r.AddHandle("POST", path, handlerFunc)
 }
 
-func (r *Router) Post(path string, handlerFunc func(http.ResponseWriter, *http.Request)) {
-	r.AddHandle("POST", path, handlerFunc)
+func (r *Router) Put(path string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return r.AddHandle("PUT", path, handlerFunc)
 }
 
-func (r *Router) Put(path string, handlerFunc func(http.ResponseWriter, *http.Request)) {
-	r.AddHandle("PUT", path, handlerFunc)
+func (r *Router) Patch(path string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return r.AddHandle("PATCH", path, handlerFunc)
 }
 
-func (r *Router) Patch(path string, handlerFunc func(http.ResponseWriter, *http.Request)) {
-	r.AddHandle("PATCH", path, handlerFunc)
+func (r *Router) Delete(path string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return r.AddHandle("DELETE", path, handlerFunc)
 }
 
-func (r *Router) Delete(path string, handlerFunc func(http.ResponseWriter, *http.Request)) {
-	r.AddHandle("DELETE", path, handlerFunc)
+// Routes returns all registered routes, including single page apps (see ServeSPA), ordered by their host (routes
+// without one first) and pattern
+func (r *Router) Routes() []RouteInfo {
+	routes := r.table.Load().routes()
+	for _, spa := range r.spas {
+		routes = append(routes, spa.route)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Host != routes[j].Host {
+			return routes[i].Host < routes[j].Host
+		}
+		return routes[i].Path.UrlPattern < routes[j].Path.UrlPattern
+	})
+	infos := make([]RouteInfo, len(routes))
+	for i, route := range routes {
+		infos[i] = RouteInfo{
+			Host:    route.Host,
+			Pattern: route.Path.UrlPattern,
+			Methods: route.Methods(),
+			Name:    route.Name,
+			Meta:    route.Meta,
+			Route:   route,
+		}
+	}
+	return infos
 }
 
+// Walk calls fn for every registered route in the same order as Routes
+func (r *Router) Walk(fn WalkFunc) error {
+	for _, info := range r.Routes() {
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetParam returns the value of the named parameter, falling back to r.PathValue,
+// so it also works for requests routed by http.ServeMux
 func GetParam(r *http.Request, key string) string {
-	params := r.Context().Value(ROUTE_PARAMS_KEY)
-	if params == nil {
-		return ""
+	rc := getRouteContext(r)
+	if rc != nil {
+		if value := rc.params.Value(key); value != "" {
+			return value
+		}
 	}
-	return params.(Params).Value(key)
+	return r.PathValue(key)
 }
 
 func GetParams(r *http.Request) Params {
-	params := r.Context().Value(ROUTE_PARAMS_KEY)
-	if params == nil {
+	rc := getRouteContext(r)
+	if rc == nil || rc.params == nil {
 		return Params{}
 	}
-	return params.(Params)
+	return rc.params
+}
+
+// GetRoute returns the route matched for the request, nil if none matched
+func GetRoute(r *http.Request) *Route {
+	rc := getRouteContext(r)
+	if rc == nil {
+		return nil
+	}
+	return rc.route
+}
+
+// RoutePattern returns the URL pattern of the route matched for the request, empty if none matched
+func RoutePattern(r *http.Request) string {
+	route := GetRoute(r)
+	if route == nil {
+		return ""
+	}
+	return route.Path.UrlPattern
+}
+
+// RouteName returns the name of the route matched for the request, empty if none matched or it has no name
+func RouteName(r *http.Request) string {
+	route := GetRoute(r)
+	if route == nil {
+		return ""
+	}
+	return route.Name
+}
+
+func getRouteContext(r *http.Request) *routeContext {
+	rc, _ := r.Context().Value(ROUTE_PARAMS_KEY).(*routeContext)
+	return rc
 }
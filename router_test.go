@@ -309,9 +309,218 @@ func TestParametersGetSet(t *testing.T) {
 	assert.Equal(t, "456", postId)
 }
 
+func TestRoutes(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.ShouldLog = false
+	router.Post("/user/:id", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/user/:id", func(w http.ResponseWriter, r *http.Request) {}).Named("user").WithMeta("owner", "accounts")
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/static/*filepath", func(w http.ResponseWriter, r *http.Request) {})
+
+	// Act
+	routes := router.Routes()
+
+	// Assert
+	assert.Equal(t, 3, len(routes))
+	assert.Equal(t, "/", routes[0].Pattern)
+	assert.Equal(t, "/static/*filepath", routes[1].Pattern)
+	assert.Equal(t, "/user/:id", routes[2].Pattern)
+	assert.Equal(t, []string{"GET", "POST"}, routes[2].Methods)
+	assert.Equal(t, "user", routes[2].Name)
+	assert.Equal(t, "accounts", routes[2].Meta["owner"])
+}
+
+func TestWalkStopsOnError(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.ShouldLog = false
+	router.Get("/a", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/b", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/c", func(w http.ResponseWriter, r *http.Request) {})
+	visited := []string{}
+	stop := fmt.Errorf("stop")
+
+	// Act
+	err := router.Walk(func(info RouteInfo) error {
+		visited = append(visited, info.Pattern)
+		if info.Pattern == "/b" {
+			return stop
+		}
+		return nil
+	})
+
+	// Assert
+	assert.Equal(t, stop, err)
+	assert.Equal(t, []string{"/a", "/b"}, visited)
+}
+
+func TestSetPathValues(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.SetPathValues = true
+	router.Get("/blog/:blog_id/post/*path", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.PathValue("blog_id") + " " + r.PathValue("path") + " " + r.Pattern + " " + GetParam(r, "blog_id")))
+	})
+	r, _ := http.NewRequest("GET", "/blog/123/post/a/b", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, "123 a/b /blog/{blog_id}/post/{path...} 123", w.Body.String())
+	assert.Equal(t, "", r.PathValue("blog_id")) // The original request is left as it was
+}
+
+func TestPathValuesOnly(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.PathValuesOnly = true
+	var route *Route
+	router.Get("/user/:id/", func(w http.ResponseWriter, r *http.Request) {
+		route = GetRoute(r)
+		w.Write([]byte(r.PathValue("id") + " " + r.Pattern + " " + GetParam(r, "id")))
+	})
+	r, _ := http.NewRequest("GET", "/user/7/", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, "7 /user/{id}/{$} 7", w.Body.String())
+	assert.Nil(t, route)
+	assert.Equal(t, "", r.PathValue("id"))
+}
+
+func TestServeMuxPatternOfSubtree(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.SetPathValues = true
+	router.HandleFunc("GET example.com/static/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Pattern))
+	})
+	r, _ := http.NewRequest("GET", "http://example.com/static/css/app.css", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, "example.com/static/", w.Body.String())
+}
+
+func TestGetParamFallsBackToPathValue(t *testing.T) {
+	// Arrange
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /user/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(GetParam(r, "id")))
+	})
+	r, _ := http.NewRequest("GET", "/user/42", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	mux.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, "42", w.Body.String())
+}
+
+func TestPooledContextsAreReused(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.PoolContexts = true
+	kept := Params{}
+	router.Get("/user/:id", func(w http.ResponseWriter, r *http.Request) {
+		if GetParam(r, "id") == "1" {
+			kept = GetParams(r).Copy()
+		}
+		w.Write([]byte(GetParam(r, "id") + RoutePattern(r)))
+	})
+
+	// Act
+	outputs := []string{}
+	for _, url := range []string{"/user/1", "/missing", "/user/2", "/user/3"} {
+		r, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		outputs = append(outputs, w.Body.String())
+	}
+
+	// Assert
+	assert.Equal(t, []string{"1/user/:id", "Not Found\n", "2/user/:id", "3/user/:id"}, outputs)
+	assert.Equal(t, Params{Param{"id", "1"}}, kept)
+}
+
+func TestPooledContextsConcurrently(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.PoolContexts = true
+	router.Use(func(next http.Handler) http.Handler { return next })
+	router.Get("/blog/:blog_id/post/:post_id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(GetParam(r, "blog_id") + "-" + GetParam(r, "post_id")))
+	})
+	done := make(chan bool)
+
+	// Act & Assert
+	for g := 0; g < 8; g++ {
+		go func(g int) {
+			for i := 0; i < 100; i++ {
+				r, _ := http.NewRequest("GET", fmt.Sprintf("/blog/%d/post/%d", g, i), nil)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, r)
+				assert.Equal(t, fmt.Sprintf("%d-%d", g, i), w.Body.String())
+			}
+			done <- true
+		}(g)
+	}
+	for g := 0; g < 8; g++ {
+		<-done
+	}
+}
+
+func TestMatchedRouteInContext(t *testing.T) {
+	// Arrange
+	var route *Route
+	pattern, name := "", ""
+	router := NewRouter()
+	router.ShouldLog = false
+	registered := router.Get("/static/path", func(w http.ResponseWriter, r *http.Request) {
+		route, pattern, name = GetRoute(r), RoutePattern(r), RouteName(r)
+	}).Named("static")
+	r, _ := http.NewRequest("GET", "/static/path", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, registered, route)
+	assert.Equal(t, "/static/path", pattern)
+	assert.Equal(t, "static", name)
+}
+
+func TestMatchedRouteWithoutMatch(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+
+	assert.Nil(t, GetRoute(r))
+	assert.Equal(t, "", RoutePattern(r))
+	assert.Equal(t, "", RouteName(r))
+}
+
 func Benchmark_Router_StaticPath(b *testing.B) {
+	benchmarkRouterStaticPath(b, false)
+}
+
+func Benchmark_Router_StaticPath_Pooled(b *testing.B) {
+	benchmarkRouterStaticPath(b, true)
+}
+
+func benchmarkRouterStaticPath(b *testing.B, pooled bool) {
 	router := NewRouter()
 	router.ShouldLog = false
+	router.PoolContexts = pooled
 
 	router.Get("/static/path", func(w http.ResponseWriter, r *http.Request) {})
 
@@ -320,24 +529,33 @@ func Benchmark_Router_StaticPath(b *testing.B) {
 }
 
 func Benchmark_Router_1_Params(b *testing.B) {
-	benchmarkRouterWithNParams(b, 5)
+	benchmarkRouterWithNParams(b, 1, false)
 }
 
 func Benchmark_Router_5_Params(b *testing.B) {
-	benchmarkRouterWithNParams(b, 5)
+	benchmarkRouterWithNParams(b, 5, false)
 }
 
 func Benchmark_Router_10_Params(b *testing.B) {
-	benchmarkRouterWithNParams(b, 10)
+	benchmarkRouterWithNParams(b, 10, false)
 }
 
 func Benchmark_Router_20_Params(b *testing.B) {
-	benchmarkRouterWithNParams(b, 20)
+	benchmarkRouterWithNParams(b, 20, false)
 }
 
-func benchmarkRouterWithNParams(b *testing.B, numParams int) {
+func Benchmark_Router_1_Params_Pooled(b *testing.B) {
+	benchmarkRouterWithNParams(b, 1, true)
+}
+
+func Benchmark_Router_20_Params_Pooled(b *testing.B) {
+	benchmarkRouterWithNParams(b, 20, true)
+}
+
+func benchmarkRouterWithNParams(b *testing.B, numParams int, pooled bool) {
 	router := NewRouter()
 	router.ShouldLog = false
+	router.PoolContexts = pooled
 
 	urlPattern := ""
 	for i := 0; i < numParams; i++ {
@@ -396,3 +614,29 @@ func (m *mockResponseWriter) WriteString(s string) (n int, err error) {
 }
 
 func (m *mockResponseWriter) WriteHeader(int) {}
+
+func TestColonInsideSegmentIsLiteral(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Post("/v1/items:batchGet", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("batch")) })
+	router.Post("/v1/items/:id", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(GetParam(r, "id"))) })
+	tcs := []struct {
+		path, expected string
+	}{
+		{"/v1/items:batchGet", "batch"},
+		{"/v1/items:other", "Not Found\n"},
+		{"/v1/itemsbatchGet", "Not Found\n"},
+		{"/v1/items/:7", ":7"},
+	}
+
+	for _, tc := range tcs {
+		r, _ := http.NewRequest("POST", tc.path, nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		router.ServeHTTP(w, r)
+
+		// Assert
+		assert.Equal(t, tc.expected, w.Body.String(), tc.path)
+	}
+}
@@ -0,0 +1,172 @@
+package yar
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// SubtreeParamKey is the name of the wildcard matching the rest of the path for
+// http.ServeMux patterns ending in '/', e.g. '/static/' is registered as '/static/*...'
+const SubtreeParamKey = "..."
+
+// ServeMuxPattern is an http.ServeMux pattern converted to yar's syntax
+type ServeMuxPattern struct {
+	Method   string   // Empty if the pattern matches all methods
+	Host     string   // Empty if the pattern matches all hosts
+	Patterns []string // yar patterns to register, two if the pattern also matches its directory (e.g. '/files/' and '/files/*path')
+}
+
+// ParseServeMuxPattern converts a pattern in http.ServeMux syntax, '[METHOD ][HOST]/[PATH]', to yar patterns:
+//   - '{name}' becomes ':name'
+//   - '{name...}' becomes '*name', also matching the directory itself like ServeMux does
+//   - a trailing '/' matches the whole subtree, as '*...' (see SubtreeParamKey)
+//   - a trailing '/{$}' matches only the path ending in '/'
+//
+// Patterns registered with Router.Handle follow ServeMux's precedence, see there.
+func ParseServeMuxPattern(pattern string) (*ServeMuxPattern, error) {
+	p := &ServeMuxPattern{}
+	rest := strings.TrimLeft(pattern, " \t")
+	if i := strings.IndexAny(rest, " \t"); i >= 0 {
+		p.Method, rest = rest[:i], strings.TrimLeft(rest[i:], " \t")
+		if p.Method == "" || strings.ContainsAny(p.Method, "/{}") {
+			return nil, fmt.Errorf("invalid method in pattern '%s'", pattern)
+		}
+	}
+	slash := strings.Index(rest, "/")
+	if slash < 0 {
+		return nil, fmt.Errorf("pattern must contain a path starting with '/', pattern=%s", pattern)
+	}
+	p.Host, rest = rest[:slash], rest[slash:]
+	if strings.Contains(p.Host, "{") {
+		return nil, fmt.Errorf("host cannot contain wildcards, pattern=%s", pattern)
+	}
+
+	segments := strings.Split(rest[1:], "/")
+	exact, wildcard := false, false
+	names := make(map[string]bool)
+	for i, segment := range segments {
+		isLast := i == len(segments)-1
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") { // Would be yar parameters
+			return nil, fmt.Errorf("segments cannot start with ':' or '*', pattern=%s", pattern)
+		}
+		if !strings.ContainsAny(segment, "{}") {
+			continue // Literal, ':' and '*' included, e.g. '/v1/items:batchGet'
+		}
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			return nil, fmt.Errorf("wildcards must span a whole path segment, pattern=%s", pattern)
+		}
+		name := segment[1 : len(segment)-1]
+		key := strings.TrimSuffix(name, "...")
+		if names[key] {
+			return nil, fmt.Errorf("duplicate wildcard name '%s', pattern=%s", key, pattern)
+		}
+		names[key] = true
+		switch {
+		case name == "$":
+			if !isLast {
+				return nil, fmt.Errorf("'{$}' must end the pattern, pattern=%s", pattern)
+			}
+			segments[i] = ""
+			exact = true
+		case strings.HasSuffix(name, "..."):
+			if !isLast {
+				return nil, fmt.Errorf("'{%s}' must end the pattern, pattern=%s", name, pattern)
+			}
+			segments[i] = "*" + strings.TrimSuffix(name, "...")
+			wildcard = true
+		default:
+			segments[i] = ":" + name
+		}
+	}
+
+	path := "/" + strings.Join(segments, "/")
+	switch {
+	case wildcard: // Also match the directory, with an empty parameter
+		p.Patterns = []string{path[:strings.LastIndex(path, "/")+1], path}
+	case !exact && strings.HasSuffix(path, "/"): // Subtree
+		p.Patterns = []string{path, path + "*" + SubtreeParamKey}
+	default:
+		p.Patterns = []string{path}
+	}
+	for _, yarPattern := range p.Patterns {
+		if _, err := parsePattern(yarPattern, false); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Handle registers a handler using http.ServeMux pattern syntax, see ParseServeMuxPattern.
+// Patterns without a method register the handler for AnyMethod, GET patterns also match HEAD unless it has a handler
+// of its own. It returns the first registered route.
+//
+// Like ServeMux, the most specific pattern wins: the wildcards of these patterns can be next to static parts, which
+// are tried first, and '{name}' is tried before '{name...}' or a trailing '/', e.g. '/' serves whatever
+// 'GET /api/items' and '/items/{id}' do not. Patterns ServeMux rejects as conflicting are resolved the same way,
+// static parts first. yar's own parameters and wildcards never give way, and wildcards at the same place must have the
+// same name; such patterns panic.
+func (r *Router) Handle(pattern string, handler http.Handler) *Route {
+	p, err := ParseServeMuxPattern(pattern)
+	if err != nil {
+		panic(err.Error())
+	}
+	method := p.Method
+	if method == "" {
+		method = AnyMethod
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			panic(fmt.Sprintf("cannot register pattern '%s': %v", pattern, p))
+		}
+	}()
+	var first *Route
+	for _, yarPattern := range p.Patterns {
+		route := r.addHandler(p.Host, method, yarPattern, handler, true)
+		if first == nil {
+			first = route
+		}
+	}
+	return first
+}
+
+// HandleFunc is Handle for functions, mirroring http.ServeMux.HandleFunc
+func (r *Router) HandleFunc(pattern string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return r.Handle(pattern, http.HandlerFunc(handlerFunc))
+}
+
+// Converts a yar pattern to http.ServeMux syntax, e.g. '/files/:id/*path' to '/files/{id}/{path...}'.
+// Patterns of serveMux routes ending in '/' match their subtree, the others need '{$}' to match only themselves.
+func serveMuxPath(pattern *Pattern, serveMux bool) string {
+	var b strings.Builder
+	subtree := false
+	for _, s := range pattern.Segments {
+		b.WriteString("/")
+		switch {
+		case s.Kind == WildcardSegment && s.Value == SubtreeParamKey:
+			subtree = true // The '/' written above already matches the subtree
+		case s.Kind == WildcardSegment:
+			b.WriteString("{" + s.Value + "...}")
+		case s.Kind == ParamSegment:
+			b.WriteString("{" + s.Value + "}")
+		default:
+			b.WriteString(s.Value)
+		}
+	}
+	path := b.String()
+	if path == "" {
+		path = "/"
+	}
+	if !serveMux && !subtree && strings.HasSuffix(path, "/") {
+		path += "{$}"
+	}
+	return path
+}
+
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
@@ -0,0 +1,202 @@
+package yar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseServeMuxPattern(t *testing.T) {
+	tcs := []struct {
+		pattern  string
+		expected ServeMuxPattern
+	}{
+		{"/items/{id}", ServeMuxPattern{"", "", []string{"/items/:id"}}},
+		{"GET /items/{id}", ServeMuxPattern{"GET", "", []string{"/items/:id"}}},
+		{"POST  example.com/items/{id}/edit", ServeMuxPattern{"POST", "example.com", []string{"/items/:id/edit"}}},
+		{"/files/{path...}", ServeMuxPattern{"", "", []string{"/files/", "/files/*path"}}},
+		{"/static/", ServeMuxPattern{"", "", []string{"/static/", "/static/*..."}}},
+		{"/static/{$}", ServeMuxPattern{"", "", []string{"/static/"}}},
+		{"/{$}", ServeMuxPattern{"", "", []string{"/"}}},
+		{"/exact", ServeMuxPattern{"", "", []string{"/exact"}}},
+		{"POST /v1/items:batchGet", ServeMuxPattern{"POST", "", []string{"/v1/items:batchGet"}}},
+	}
+
+	for _, tc := range tcs {
+		p, err := ParseServeMuxPattern(tc.pattern)
+		assert.Nil(t, err, tc.pattern)
+		assert.Equal(t, &tc.expected, p, tc.pattern)
+	}
+}
+
+func TestParseServeMuxPatternErrors(t *testing.T) {
+	patterns := []string{
+		"",
+		"GET",
+		"GET items",
+		"/items/id{id}",
+		"/items/{id",
+		"/{path...}/edit",
+		"/{$}/edit",
+		"{host}/items",
+		"/items/{}",
+		"/items/:id",
+		"/files/*path",
+		"/{id}/{id}",
+	}
+
+	for _, pattern := range patterns {
+		_, err := ParseServeMuxPattern(pattern)
+		assert.NotNil(t, err, pattern)
+	}
+}
+
+func TestHandleServeMuxPatterns(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	respond := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body + " " + GetParam(r, "id") + GetParam(r, "path") + GetParam(r, SubtreeParamKey)))
+		}
+	}
+	router.HandleFunc("GET /items/{id}", respond("get item"))
+	router.HandleFunc("/items/{id}/any", respond("any method"))
+	router.HandleFunc("/files/{path...}", respond("file"))
+	router.HandleFunc("/static/", respond("static"))
+	router.HandleFunc("api.example.com/items/{id}", respond("api item"))
+	router.HandleFunc("/v1/items:batchGet", respond("batch"))
+
+	tcs := []struct {
+		method, host, path, expected string
+	}{
+		{"GET", "example.com", "/items/1", "get item 1"},
+		{"DELETE", "example.com", "/items/1/any", "any method 1"},
+		{"GET", "example.com", "/files/", "file "},
+		{"GET", "example.com", "/files/a/b.txt", "file a/b.txt"},
+		{"GET", "example.com", "/static/", "static "},
+		{"GET", "example.com", "/static/css/site.css", "static css/site.css"},
+		{"PUT", "api.example.com:8080", "/items/2", "api item 2"},
+		{"GET", "api.example.com", "/files/x", "file x"}, // Falls back to routes without a host
+		{"POST", "example.com", "/v1/items:batchGet", "batch "},
+		{"POST", "example.com", "/v1/items:other", "Not Found\n"},
+	}
+
+	for _, tc := range tcs {
+		// Act
+		r, _ := http.NewRequest(tc.method, "http://"+tc.host+tc.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		// Assert
+		assert.Equal(t, tc.expected, w.Body.String(), tc.method+" "+tc.host+tc.path)
+	}
+}
+
+func TestHandleServeMuxMethodNotAllowed(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	r, _ := http.NewRequest("POST", "/items/1", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestRoutesIncludeHosts(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("GET api.example.com/items", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleFunc("GET /items", func(w http.ResponseWriter, r *http.Request) {})
+
+	routes := router.Routes()
+
+	assert.Equal(t, 2, len(routes))
+	assert.Equal(t, "", routes[0].Host)
+	assert.Equal(t, "api.example.com", routes[1].Host)
+	assert.Equal(t, "/items", routes[1].Pattern)
+}
+
+func TestAddHandlerWithDifferentParamNamePanics(t *testing.T) {
+	router := NewRouter()
+	router.Get("/user/:id", func(w http.ResponseWriter, r *http.Request) {})
+
+	assert.PanicsWithValue(t, "cannot have two different parameter names for the same path part, e.g.: [/user/:user_id,/user/:user]", func() {
+		router.Post("/user/:user_id", func(w http.ResponseWriter, r *http.Request) {})
+	})
+}
+
+func TestHandleServeMuxPrecedence(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	respond := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body + " " + GetParam(r, "id") + GetParam(r, "rest") + GetParam(r, SubtreeParamKey)))
+		}
+	}
+	router.HandleFunc("/", respond("root"))
+	router.HandleFunc("GET /api/items", respond("api items"))
+	router.HandleFunc("/items/{rest...}", respond("rest"))
+	router.HandleFunc("GET /items/{id}", respond("item"))
+	router.HandleFunc("GET /items/new", respond("new item"))
+	router.HandleFunc("/static/", respond("static"))
+	router.Get("/static/special", respond("special"))
+
+	tcs := []struct {
+		path, expected string
+	}{
+		{"/", "root "},
+		{"/about", "root about"},
+		{"/api/items", "api items "},
+		{"/api/items/1", "root api/items/1"},
+		{"/items/new", "new item "},
+		{"/items/newer", "item newer"},
+		{"/items/5", "item 5"},
+		{"/items/5/edit", "rest 5/edit"},
+		{"/items/", "rest "},
+		{"/static/special", "special "},
+		{"/static/site.css", "static site.css"},
+	}
+
+	for _, tc := range tcs {
+		// Act
+		r, _ := http.NewRequest("GET", tc.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		// Assert
+		assert.Equal(t, tc.expected, w.Body.String(), tc.path)
+	}
+}
+
+func TestHandleServeMuxGetMatchesHead(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) { w.Header().Set("X-Handler", "get") })
+	router.HandleFunc("GET /files/{path...}", func(w http.ResponseWriter, r *http.Request) { w.Header().Set("X-Handler", "get") })
+	router.HandleFunc("HEAD /files/{path...}", func(w http.ResponseWriter, r *http.Request) { w.Header().Set("X-Handler", "head") })
+
+	for path, expected := range map[string]string{"/items/1": "get", "/files/a.txt": "head", "/files/": "head"} {
+		// Act
+		r, _ := http.NewRequest("HEAD", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Equal(t, expected, w.Header().Get("X-Handler"), path)
+	}
+}
+
+func TestHandleServeMuxPatternNextToYarParameterPanics(t *testing.T) {
+	router := NewRouter()
+	router.Get("/items/:id", func(w http.ResponseWriter, r *http.Request) {})
+
+	assert.PanicsWithValue(t, "cannot register pattern 'GET /items/new': parameter and static parts of the path cannot be in the same place, e.g.: [/blog/:blog_id,/blog/new]", func() {
+		router.HandleFunc("GET /items/new", func(w http.ResponseWriter, r *http.Request) {})
+	})
+}
@@ -0,0 +1,123 @@
+package yar
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// SPAOptions configures Router.ServeSPA
+type SPAOptions struct {
+	Index         string   // Served for unknown paths, 'index.html' if empty
+	Exclude       []string // Path prefixes keeping real 404s, e.g. '/api/'
+	AssetPrefixes []string // Path prefixes of the app's assets, e.g. '/assets/', missing files under them are not found
+	Precompressed bool     // See FileServerOptions.Precompressed
+}
+
+// ServeSPA serves a single page app's files from fsys, e.g. an embed.FS, for GET and HEAD requests under prefix
+// that no route matches, so it coexists with the router's other routes, even with prefix '/':
+//
+//	router.Get("/api/users", listUsers)
+//	router.ServeSPA("/", dist, &yar.SPAOptions{Exclude: []string{"/api/"}})
+//
+// Paths without a file fall back to the index file, letting the app route them with the history API, even with an
+// extension like '/users/john.doe'. Missing assets are still not found: files under AssetPrefixes, and files with an
+// extension requested without accepting text/html (as browsers do for scripts, styles and images, but not pages).
+// Paths under an excluded prefix are not found either. Files are served like ServeFiles serves them.
+//
+// The app is listed by Routes, as GET and HEAD on prefix followed by '*filepath', so it shows up in the debug handler
+// and the OpenAPI document.
+func (r *Router) ServeSPA(prefix string, fsys fs.FS, opts *SPAOptions) {
+	if !strings.HasPrefix(prefix, "/") {
+		panic("single page app prefix must start with '/', prefix=" + prefix)
+	}
+	if opts == nil {
+		opts = &SPAOptions{}
+	}
+	spa := &spaHandler{
+		prefix:  strings.TrimSuffix(prefix, "/") + "/",
+		index:   opts.Index,
+		exclude: opts.Exclude,
+		assets:  opts.AssetPrefixes,
+		files:   newFileServer(r, fsys, FileServerOptions{Precompressed: opts.Precompressed}),
+	}
+	if spa.index == "" {
+		spa.index = "index.html"
+	}
+	spa.route = NewRoute(spa.prefix + "*filepath")
+	spa.route.Handlers["GET"] = spa
+	spa.route.Handlers["HEAD"] = spa
+	r.spas = append(r.spas, spa)
+	sort.SliceStable(r.spas, func(i, j int) bool { // Longest prefix first
+		return len(r.spas[i].prefix) > len(r.spas[j].prefix)
+	})
+}
+
+type spaHandler struct {
+	prefix  string // Ends with '/'
+	index   string
+	exclude []string
+	assets  []string
+	files   *fileServer
+	route   *Route // Describes the app in Routes, it is not in the route trie
+}
+
+// Returns the single page app serving a request no route matched, nil if there is none
+func (r *Router) findSPA(req *http.Request) *spaHandler {
+	if req.Method != "GET" && req.Method != "HEAD" {
+		return nil
+	}
+	for _, spa := range r.spas {
+		if spa.matches(req.URL.Path) {
+			return spa
+		}
+	}
+	return nil
+}
+
+func (spa *spaHandler) matches(urlPath string) bool {
+	if !strings.HasPrefix(urlPath, spa.prefix) && urlPath+"/" != spa.prefix {
+		return false
+	}
+	for _, excluded := range spa.exclude {
+		if strings.HasPrefix(urlPath, excluded) {
+			return false
+		}
+	}
+	return true
+}
+
+func (spa *spaHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rel := strings.TrimPrefix(req.URL.Path, spa.prefix)
+	if req.URL.Path+"/" == spa.prefix {
+		rel = ""
+	}
+	if name, ok := cleanFilePath(rel); ok {
+		if _, err := fs.Stat(spa.files.fsys, name); err == nil {
+			spa.files.serve(w, req, rel)
+			return
+		}
+	}
+	if spa.isAsset(req, rel) { // The app's index would only confuse the browser
+		spa.files.router.handleNotFound(w, req)
+		return
+	}
+	info, err := fs.Stat(spa.files.fsys, spa.index)
+	if err != nil || info.IsDir() {
+		spa.files.router.handleNotFound(w, req)
+		return
+	}
+	spa.files.serveFile(w, req, spa.index, info)
+}
+
+// Whether a missing file is one of the app's assets rather than a page the app routes
+func (spa *spaHandler) isAsset(req *http.Request, rel string) bool {
+	for _, prefix := range spa.assets {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			return true
+		}
+	}
+	return path.Ext(rel) != "" && !strings.Contains(req.Header.Get("Accept"), "text/html")
+}
@@ -0,0 +1,127 @@
+package yar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSPAFS() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":           {Data: []byte("<div id=app></div>"), ModTime: testModTime},
+		"assets/app.js":        {Data: []byte("app()"), ModTime: testModTime},
+		"assets/app.css":       {Data: []byte("#app {}"), ModTime: testModTime},
+		"favicon.ico":          {Data: []byte("icon"), ModTime: testModTime},
+		"admin/index.html":     {Data: []byte("admin app"), ModTime: testModTime},
+		"admin/assets/main.js": {Data: []byte("admin()"), ModTime: testModTime},
+	}
+}
+
+func TestServeSPA(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/api/users", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("users")) })
+	router.ServeSPA("/", newTestSPAFS(), &SPAOptions{Exclude: []string{"/api/"}, AssetPrefixes: []string{"/assets/"}})
+
+	tcs := []struct {
+		method, path, accept string
+		expectedCode         int
+		expectedBody         string
+	}{
+		{"GET", "/", "", http.StatusOK, "<div id=app></div>"},
+		{"GET", "/assets/app.js", "", http.StatusOK, "app()"},
+		{"GET", "/favicon.ico", "", http.StatusOK, "icon"},
+		{"GET", "/users/42/edit", "", http.StatusOK, "<div id=app></div>"}, // Routed by the app
+		{"GET", "/users/john.doe", "text/html,*/*;q=0.8", http.StatusOK, "<div id=app></div>"},
+		{"GET", "/v/1.2", "text/html", http.StatusOK, "<div id=app></div>"},
+		{"HEAD", "/settings", "", http.StatusOK, ""},
+		{"GET", "/missing.js", "*/*", http.StatusNotFound, "Not Found\n"},
+		{"GET", "/assets/missing.css", "", http.StatusNotFound, "Not Found\n"},
+		{"GET", "/assets/missing", "text/html", http.StatusNotFound, "Not Found\n"},
+		{"GET", "/api/users", "", http.StatusOK, "users"},
+		{"GET", "/api/unknown", "", http.StatusNotFound, "Not Found\n"},
+		{"POST", "/users", "", http.StatusNotFound, "Not Found\n"},
+		{"GET", "/../secret", "", http.StatusOK, "<div id=app></div>"},
+	}
+
+	for _, tc := range tcs {
+		// Arrange
+		r, _ := http.NewRequest(tc.method, tc.path, nil)
+		r.Header.Set("Accept", tc.accept)
+		w := httptest.NewRecorder()
+
+		// Act
+		router.ServeHTTP(w, r)
+
+		// Assert
+		assert.Equal(t, tc.expectedCode, w.Code, tc.method+" "+tc.path)
+		assert.Equal(t, tc.expectedBody, w.Body.String(), tc.method+" "+tc.path)
+	}
+}
+
+func TestServeSPAIsListed(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/api/users", func(w http.ResponseWriter, r *http.Request) {})
+	router.ServeSPA("/app", newTestSPAFS(), nil)
+	r, _ := http.NewRequest("GET", "/app2", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	routes := router.Routes()
+	doc := router.OpenAPIHost("example.com", OpenAPIInfo{})
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, 2, len(routes))
+	assert.Equal(t, "/app/*filepath", routes[1].Pattern)
+	assert.Equal(t, []string{"GET", "HEAD"}, routes[1].Methods)
+	assert.NotNil(t, doc.Paths["/app/{filepath}"])
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServeSPAUnderPrefix(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	admin, _ := newTestSPAFS().Sub("admin")
+	router.ServeSPA("/", newTestSPAFS(), nil)
+	router.ServeSPA("/admin", admin, nil)
+
+	tcs := []struct {
+		path, expectedBody string
+	}{
+		{"/admin", "admin app"},
+		{"/admin/", "admin app"},
+		{"/admin/users/1", "admin app"},
+		{"/admin/assets/main.js", "admin()"},
+		{"/administrators", "<div id=app></div>"},
+		{"/other", "<div id=app></div>"},
+	}
+
+	for _, tc := range tcs {
+		// Arrange
+		r, _ := http.NewRequest("GET", tc.path, nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		router.ServeHTTP(w, r)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code, tc.path)
+		assert.Equal(t, tc.expectedBody, w.Body.String(), tc.path)
+	}
+}
+
+func TestServeSPAWithoutIndex(t *testing.T) {
+	router := NewRouter()
+	router.ServeSPA("/", fstest.MapFS{"app.js": {Data: []byte("app()")}}, nil)
+	r, _ := http.NewRequest("GET", "/page", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
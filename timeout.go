@@ -0,0 +1,201 @@
+package yar
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Timeout returns a middleware putting a deadline on the request's context, for a route or a group:
+//
+//	router.Get("/report", report).Use(router.Timeout(10 * time.Second))
+//
+// If the handler hasn't responded when the deadline expires, the router's TimeoutHandler writes the response
+// instead and an EventTimeout is logged. Unlike http.TimeoutHandler it supports streaming: once the handler
+// flushes, its response is sent as it is written, and the deadline can then only cancel the context and make
+// later writes fail with http.ErrHandlerTimeout. Header changes after a flush are copied on the next flush and
+// when the handler returns, so trailers are sent.
+//
+// Handler panics are raised again in the serving goroutine with the stack they happened in, for Router.PanicHandler
+// and EventPanic. Panics after the handler was given up on have no one left to recover them and are only logged as
+// an EventPanic.
+func (r *Router) Timeout(timeout time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, ctx: ctx, header: make(http.Header), status: http.StatusOK}
+			done := make(chan struct{})
+			panicked := make(chan interface{})
+			abandoned := make(chan struct{}) // Closed once the middleware returned
+			defer close(abandoned)
+			go func() {
+				defer func() {
+					p := recover()
+					if p == nil {
+						return
+					}
+					if _, wrapped := p.(*goroutinePanic); !wrapped && p != http.ErrAbortHandler {
+						p = &goroutinePanic{value: p, stack: debug.Stack()}
+					}
+					select {
+					case panicked <- p:
+					case <-abandoned:
+						if gp, ok := p.(*goroutinePanic); ok {
+							r.emitEvent(Event{Kind: EventPanic, Request: req, Route: GetRoute(req), Params: GetParams(req), Panic: gp.value, Stack: gp.stack})
+						}
+					}
+				}()
+				next.ServeHTTP(tw, req.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked: // Passed on to the serving goroutine, e.g. for Router.PanicHandler
+				panic(p)
+			case <-done:
+			case <-ctx.Done():
+			}
+			if ctx.Err() == nil {
+				tw.finish()
+				return
+			}
+
+			select {
+			case <-done:
+			default:
+				if rc := getRouteContext(req); rc != nil {
+					rc.detached = true // The handler is still using it
+				}
+			}
+			if ctx.Err() != context.DeadlineExceeded { // The client went away, nothing left to respond to
+				tw.abort(ctx.Err())
+				return
+			}
+			started := tw.abort(http.ErrHandlerTimeout)
+			r.emitEvent(Event{Kind: EventTimeout, Request: req, Route: GetRoute(req), Params: GetParams(req), Timeout: timeout})
+			if !started {
+				r.handleTimeout(w, req)
+			}
+		})
+	}
+}
+
+func (r *Router) handleTimeout(w http.ResponseWriter, req *http.Request) {
+	if r.TimeoutHandler != nil {
+		r.TimeoutHandler.ServeHTTP(w, req)
+	} else {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	}
+}
+
+// timeoutWriter buffers the response until the handler returns or flushes, so a timeout can still replace it
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	ctx    context.Context
+	header http.Header
+
+	mu          sync.Mutex
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	started     bool  // Flushed, written straight through from then on
+	err         error // Set when the deadline expired, returned by later writes
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	switch {
+	case tw.expired() != nil:
+	case tw.started:
+		tw.w.WriteHeader(status)
+	case !tw.wroteHeader && status >= 200: // Informational responses are dropped while buffering
+		tw.status = status
+		tw.wroteHeader = true
+	}
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if err := tw.expired(); err != nil {
+		return 0, err
+	}
+	if tw.started {
+		return tw.w.Write(b)
+	}
+	tw.wroteHeader = true
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.expired() != nil {
+		return
+	}
+	if tw.started {
+		tw.copyHeader() // Keys added since the last flush, e.g. trailers
+	}
+	tw.start()
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Fails writes once the deadline expired, even before abort is called, must be called with mu held
+func (tw *timeoutWriter) expired() error {
+	if tw.err == nil && tw.ctx.Err() == context.DeadlineExceeded {
+		tw.err = http.ErrHandlerTimeout
+	}
+	return tw.err
+}
+
+// Writes the header and buffered body, must be called with mu held
+func (tw *timeoutWriter) start() {
+	if tw.started {
+		return
+	}
+	tw.started = true
+	tw.copyHeader()
+	tw.w.WriteHeader(tw.status)
+	if tw.buf.Len() > 0 {
+		tw.w.Write(tw.buf.Bytes())
+		tw.buf.Reset()
+	}
+}
+
+// Copies the handler's header to the wrapped writer, where it only matters for trailers once started
+func (tw *timeoutWriter) copyHeader() {
+	header := tw.w.Header()
+	for key, values := range tw.header {
+		header[key] = values
+	}
+}
+
+// Called once the handler returned in time
+func (tw *timeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.started {
+		tw.copyHeader() // Trailers set after the last flush
+	}
+	tw.start()
+}
+
+// Rejects any further writes, returning whether the response had already been started
+func (tw *timeoutWriter) abort(err error) bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.err = err
+	return tw.started
+}
@@ -0,0 +1,238 @@
+package yar
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutRespondsWhenHandlerIsSlow(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	var events []Event
+	router.Logger = LoggerFunc(func(e Event) { events = append(events, e) })
+	handlerErr := make(chan error, 1)
+	router.Get("/slow/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Discarded", "1")
+		<-r.Context().Done()
+		handlerErr <- r.Context().Err()
+		w.Write([]byte("too late"))
+	}).Use(router.Timeout(10 * time.Millisecond))
+	r, _ := http.NewRequest("GET", "/slow/3", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "", w.Header().Get("X-Discarded"))
+	assert.Equal(t, context.DeadlineExceeded, <-handlerErr)
+	last := events[len(events)-1]
+	assert.Equal(t, EventTimeout, last.Kind)
+	assert.Equal(t, "/slow/:id", last.Route.Path.UrlPattern)
+	assert.Equal(t, "3", last.Params.Value("id"))
+	assert.Equal(t, 10*time.Millisecond, last.Timeout)
+}
+
+func TestTimeoutPassesResponseWhenInTime(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/fast", func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline := r.Context().Deadline()
+		assert.True(t, hasDeadline)
+		w.Header().Set("X-Kept", "1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("done"))
+	}).Use(router.Timeout(time.Second))
+	r, _ := http.NewRequest("GET", "/fast", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Kept"))
+	assert.Equal(t, "done", w.Body.String())
+}
+
+func TestTimeoutStreamingHandler(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	writeErr := make(chan error, 1)
+	router.Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("chunk1,"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("chunk2,"))
+		<-r.Context().Done()
+		_, err := w.Write([]byte("chunk3"))
+		writeErr <- err
+	}).Use(router.Timeout(10 * time.Millisecond))
+	r, _ := http.NewRequest("GET", "/stream", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "chunk1,chunk2,", w.Body.String())
+	assert.True(t, w.Flushed)
+	assert.Equal(t, http.ErrHandlerTimeout, <-writeErr)
+}
+
+func TestTimeoutCopiesHeaderAfterFlush(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Checksum")
+		w.Write([]byte("chunk1,"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("chunk2"))
+		w.Header().Set("X-Checksum", "abc")
+	}).Use(router.Timeout(time.Second))
+	s := httptest.NewServer(router)
+	defer s.Close()
+
+	// Act
+	res, err := http.Get(s.URL + "/stream")
+
+	// Assert
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal(t, "chunk1,chunk2", string(body))
+	assert.Equal(t, "abc", res.Trailer.Get("X-Checksum"))
+}
+
+func TestTimeoutCustomHandlerOnGroup(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.TimeoutHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "timed out", http.StatusGatewayTimeout)
+	})
+	api := router.Group("/api").Use(router.Timeout(10 * time.Millisecond))
+	api.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	r, _ := http.NewRequest("GET", "/api/slow", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Equal(t, "timed out\n", w.Body.String())
+}
+
+func TestTimeoutPanicIsRecovered(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.PanicHandler = DefaultPanicHandler
+	router.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}).Use(router.Timeout(time.Second))
+	r, _ := http.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestTimeoutPanicKeepsStack(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	var events []Event
+	router.Logger = LoggerFunc(func(e Event) { events = append(events, e) })
+	var recovered interface{}
+	var stack []byte
+	router.PanicHandler = func(w http.ResponseWriter, req *http.Request, rec interface{}, s []byte) {
+		recovered, stack = rec, s
+		DefaultPanicHandler(w, req, rec, s)
+	}
+	router.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}).Use(router.Timeout(time.Second))
+	r, _ := http.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "boom", recovered)
+	assert.Contains(t, string(stack), "yar.TestTimeoutPanicKeepsStack.func")
+	last := events[len(events)-1]
+	assert.Equal(t, EventPanic, last.Kind)
+	assert.Equal(t, "boom", last.Panic)
+	assert.Contains(t, string(last.Stack), "yar.TestTimeoutPanicKeepsStack.func")
+}
+
+func TestTimeoutLatePanicIsLogged(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	panics := make(chan Event, 1)
+	router.Logger = LoggerFunc(func(e Event) {
+		if e.Kind == EventPanic {
+			panics <- e
+		}
+	})
+	release := make(chan struct{})
+	router.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		panic("late")
+	}).Use(router.Timeout(10 * time.Millisecond))
+	r, _ := http.NewRequest("GET", "/slow", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+	close(release)
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	select {
+	case e := <-panics:
+		assert.Equal(t, "late", e.Panic)
+		assert.Equal(t, "/slow", e.Route.Path.UrlPattern)
+		assert.NotEmpty(t, e.Stack)
+	case <-time.After(time.Second):
+		t.Fatal("late panic was not logged")
+	}
+}
+
+func TestTimeoutWithPooledContexts(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.PoolContexts = true
+	release := make(chan struct{})
+	late := make(chan string, 1)
+	router.Get("/slow/:id", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		late <- GetParam(r, "id") // Still valid after the router returned
+	}).Use(router.Timeout(10 * time.Millisecond))
+	router.Get("/fast/:id", func(w http.ResponseWriter, r *http.Request) {})
+	rSlow, _ := http.NewRequest("GET", "/slow/1", nil)
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), rSlow)
+	for i := 0; i < 10; i++ {
+		rFast, _ := http.NewRequest("GET", "/fast/2", nil)
+		router.ServeHTTP(httptest.NewRecorder(), rFast)
+	}
+	close(release)
+
+	// Assert
+	assert.Equal(t, "1", <-late)
+}
@@ -0,0 +1,214 @@
+package yar
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpanContext identifies a span across process boundaries, as carried by the W3C traceparent and tracestate headers
+type SpanContext struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	Flags      byte   // Bit 0 is the sampled flag
+	TraceState string // Vendor specific data, passed on as is
+}
+
+const traceparentSampled byte = 0x01
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&traceparentSampled != 0
+}
+
+func (sc SpanContext) TraceIDString() string {
+	return hex.EncodeToString(sc.TraceID[:])
+}
+
+func (sc SpanContext) SpanIDString() string {
+	return hex.EncodeToString(sc.SpanID[:])
+}
+
+// Traceparent formats the span context as a version 00 traceparent header value
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceIDString(), sc.SpanIDString(), sc.Flags)
+}
+
+// ParseTraceparent parses a traceparent header value, e.g. '00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01'
+func ParseTraceparent(value string) (SpanContext, error) {
+	sc := SpanContext{}
+	// Later versions may append fields, but must keep the version 00 ones
+	if len(value) < 55 || (len(value) > 55 && value[55] != '-') {
+		return sc, fmt.Errorf("invalid traceparent length, traceparent=%s", value)
+	}
+	parts := strings.Split(value[:55], "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, fmt.Errorf("invalid traceparent format, traceparent=%s", value)
+	}
+	if strings.ToLower(value) != value {
+		return sc, fmt.Errorf("traceparent must be lower case hex, traceparent=%s", value)
+	}
+	version, err := hex.DecodeString(parts[0])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(value) != 55) {
+		return sc, fmt.Errorf("invalid traceparent version, traceparent=%s", value)
+	}
+	flags, err1 := hex.DecodeString(parts[3])
+	_, err2 := hex.Decode(sc.TraceID[:], []byte(parts[1]))
+	_, err3 := hex.Decode(sc.SpanID[:], []byte(parts[2]))
+	if err1 != nil || err2 != nil || err3 != nil {
+		return SpanContext{}, fmt.Errorf("traceparent is not valid hex, traceparent=%s", value)
+	}
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("traceparent trace and span ids cannot be all zeros, traceparent=%s", value)
+	}
+	return sc, nil
+}
+
+// ExtractTraceHeaders reads the traceparent and tracestate headers, the returned span context is invalid if there are none
+func ExtractTraceHeaders(header http.Header) SpanContext {
+	sc, err := ParseTraceparent(header.Get("traceparent"))
+	if err != nil {
+		return SpanContext{}
+	}
+	sc.TraceState = strings.Join(header.Values("tracestate"), ",")
+	return sc
+}
+
+// InjectTraceHeaders writes the context's span as traceparent and tracestate headers, e.g. on an outgoing request
+func InjectTraceHeaders(ctx context.Context, header http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	header.Set("traceparent", sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set("tracestate", sc.TraceState)
+	} else {
+		header.Del("tracestate")
+	}
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a context carrying the span context, used by Tracer implementations
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the current span, or the remote parent span if the router's tracer has not started one
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc
+}
+
+// Tracer starts spans for matched requests, see Router.Tracer. Implementations can adapt it to OpenTelemetry.
+type Tracer interface {
+	// Start creates a span, the child of SpanContextFromContext(ctx) if it is valid.
+	// The returned context must carry the new span, e.g. by using ContextWithSpanContext.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type Span interface {
+	SpanContext() SpanContext
+	SetAttribute(key string, value interface{})
+	End()
+}
+
+// Starts a span named after the route's pattern, around the method handler
+func (r *Router) serveTraced(w http.ResponseWriter, req *http.Request, route *Route, handler http.Handler) {
+	ctx := req.Context()
+	if remote := ExtractTraceHeaders(req.Header); remote.IsValid() {
+		ctx = ContextWithSpanContext(ctx, remote)
+	}
+	ctx, span := r.Tracer.Start(ctx, route.Path.UrlPattern)
+	span.SetAttribute("http.request.method", req.Method)
+	span.SetAttribute("http.route", route.Path.UrlPattern)
+	span.SetAttribute("url.path", req.URL.Path)
+	if route.Name != "" {
+		span.SetAttribute("yar.route.name", route.Name)
+	}
+
+	rw := newResponseWriter(w)
+	defer func() {
+		if p := recover(); p != nil { // Recorded as the 500 Internal Server Error a recovered panic gets
+			span.SetAttribute("http.response.status_code", http.StatusInternalServerError)
+			span.SetAttribute("error.type", "panic")
+			span.SetAttribute("exception.message", fmt.Sprint(p))
+			span.End()
+			panic(p)
+		}
+		span.SetAttribute("http.response.status_code", rw.status)
+		span.End()
+	}()
+	handler.ServeHTTP(rw.writer(), req.WithContext(ctx))
+}
+
+// TraceRecorder is a Tracer keeping finished spans in memory, meant for tests
+type TraceRecorder struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+type RecordedSpan struct {
+	Name       string
+	Context    SpanContext
+	Parent     SpanContext // Invalid for root spans
+	Attributes map[string]interface{}
+	StartTime  time.Time
+	EndTime    time.Time
+
+	recorder *TraceRecorder
+}
+
+func NewTraceRecorder() *TraceRecorder {
+	return &TraceRecorder{}
+}
+
+func (tr *TraceRecorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent := SpanContextFromContext(ctx)
+	span := &RecordedSpan{
+		Name:       name,
+		Parent:     parent,
+		Attributes: make(map[string]interface{}),
+		StartTime:  time.Now(),
+		recorder:   tr,
+	}
+	span.Context = SpanContext{TraceID: parent.TraceID, Flags: parent.Flags, TraceState: parent.TraceState}
+	if !parent.IsValid() {
+		rand.Read(span.Context.TraceID[:])
+		span.Context.Flags = traceparentSampled
+	}
+	rand.Read(span.Context.SpanID[:])
+	return ContextWithSpanContext(ctx, span.Context), span
+}
+
+// Spans returns the ended spans in the order they ended
+func (tr *TraceRecorder) Spans() []*RecordedSpan {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return append([]*RecordedSpan{}, tr.spans...)
+}
+
+func (s *RecordedSpan) SpanContext() SpanContext {
+	return s.Context
+}
+
+func (s *RecordedSpan) SetAttribute(key string, value interface{}) {
+	s.Attributes[key] = value
+}
+
+func (s *RecordedSpan) End() {
+	s.EndTime = time.Now()
+	s.recorder.mu.Lock()
+	s.recorder.spans = append(s.recorder.spans, s)
+	s.recorder.mu.Unlock()
+}
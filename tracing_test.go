@@ -0,0 +1,113 @@
+package yar
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceIDString())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanIDString())
+	assert.True(t, sc.IsSampled())
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.Traceparent())
+}
+
+func TestParseTraceparentFutureVersion(t *testing.T) {
+	sc, err := ParseTraceparent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+
+	assert.Nil(t, err)
+	assert.False(t, sc.IsSampled())
+}
+
+func TestParseTraceparentErrors(t *testing.T) {
+	values := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01",
+	}
+
+	for _, value := range values {
+		_, err := ParseTraceparent(value)
+		assert.NotNil(t, err, value)
+	}
+}
+
+func TestTracerSpansMatchedRoutes(t *testing.T) {
+	// Arrange
+	recorder := NewTraceRecorder()
+	router := NewRouter()
+	router.Tracer = recorder
+	outgoing := http.Header{}
+	router.Get("/user/:id", func(w http.ResponseWriter, r *http.Request) {
+		InjectTraceHeaders(r.Context(), outgoing)
+		w.WriteHeader(http.StatusAccepted)
+	}).Named("user")
+	r, _ := http.NewRequest("GET", "/user/1", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Header.Set("tracestate", "vendor=value")
+	rNotFound, _ := http.NewRequest("GET", "/missing", nil)
+
+	// Act
+	router.ServeHTTP(httptest.NewRecorder(), r)
+	router.ServeHTTP(httptest.NewRecorder(), rNotFound)
+
+	// Assert
+	spans := recorder.Spans()
+	assert.Equal(t, 1, len(spans))
+	span := spans[0]
+	assert.Equal(t, "/user/:id", span.Name)
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanIDString())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.Context.TraceIDString())
+	assert.Equal(t, "vendor=value", span.Context.TraceState)
+	assert.Equal(t, http.StatusAccepted, span.Attributes["http.response.status_code"])
+	assert.Equal(t, "GET", span.Attributes["http.request.method"])
+	assert.Equal(t, "user", span.Attributes["yar.route.name"])
+	assert.Equal(t, span.Context.Traceparent(), outgoing.Get("traceparent"))
+	assert.Equal(t, "vendor=value", outgoing.Get("tracestate"))
+}
+
+func TestTracerRecordsPanics(t *testing.T) {
+	// Arrange
+	recorder := NewTraceRecorder()
+	router := NewRouter()
+	router.Tracer = recorder
+	router.PanicHandler = DefaultPanicHandler
+	router.Get("/panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	r, _ := http.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	span := recorder.Spans()[0]
+	assert.Equal(t, http.StatusInternalServerError, span.Attributes["http.response.status_code"])
+	assert.Equal(t, "panic", span.Attributes["error.type"])
+	assert.Equal(t, "boom", span.Attributes["exception.message"])
+	assert.False(t, span.EndTime.IsZero())
+}
+
+func TestTraceRecorderStartsRootSpans(t *testing.T) {
+	recorder := NewTraceRecorder()
+
+	ctx, span := recorder.Start(context.Background(), "root")
+	span.End()
+
+	assert.True(t, span.SpanContext().IsValid())
+	assert.True(t, span.SpanContext().IsSampled())
+	assert.False(t, recorder.Spans()[0].Parent.IsValid())
+	assert.Equal(t, span.SpanContext(), SpanContextFromContext(ctx))
+}
@@ -0,0 +1,188 @@
+package yar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// HTTPError is an error responded to with its status code. Message is sent to the client, Err is not.
+type HTTPError struct {
+	Status  int
+	Message string // If empty, the status text is used
+	Err     error
+}
+
+// NewHTTPError returns an error responded to with the status code and message
+func NewHTTPError(status int, message string) *HTTPError {
+	return &HTTPError{Status: status, Message: message}
+}
+
+func (e *HTTPError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.Status)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *HTTPError) StatusCode() int {
+	return e.Status
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// DefaultErrorHandler responds with an ErrorResponse. The status code is taken from the first error in the chain with
+// a 'StatusCode() int' method (e.g. *HTTPError), it is 504 Gateway Timeout for context.DeadlineExceeded and 500 Internal
+// Server Error otherwise. Only messages of *HTTPError are sent, other errors get the status text.
+func DefaultErrorHandler(w http.ResponseWriter, req *http.Request, err error) {
+	status := http.StatusInternalServerError
+	var statusErr interface{ StatusCode() int }
+	if errors.As(err, &statusErr) {
+		status = statusErr.StatusCode()
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		status = http.StatusGatewayTimeout
+	}
+	message := http.StatusText(status)
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.Message != "" {
+		message = httpErr.Message
+	}
+	WriteErrorResponse(w, ErrorResponse{Status: status, Error: message})
+}
+
+// Responds to an error with the router's handlers, *BindError and *ValidationError with ValidationErrorHandler
+func (r *Router) handleError(w http.ResponseWriter, req *http.Request, err error) {
+	var bindErr *BindError
+	var validationErr *ValidationError
+	if errors.As(err, &bindErr) || errors.As(err, &validationErr) {
+		if r != nil && r.ValidationErrorHandler != nil {
+			r.ValidationErrorHandler(w, req, err)
+		} else {
+			DefaultValidationErrorHandler(w, req, err)
+		}
+		return
+	}
+	if r != nil && r.ErrorHandler != nil {
+		r.ErrorHandler(w, req, err)
+	} else {
+		DefaultErrorHandler(w, req, err)
+	}
+}
+
+// HandlerTypesKey is the method metadata key under which Handle stores the HandlerTypes
+type HandlerTypesKey struct{}
+
+// HandlerTypes are the request and response types of a handler registered with Handle, used to generate OpenAPI schemas
+type HandlerTypes struct {
+	Request  reflect.Type
+	Response reflect.Type
+}
+
+// Handle registers a function taking a request struct and returning a response, removing the usual handler boilerplate:
+//
+//	type GetUser struct {
+//	    ID      int  `path:"id" validate:"min=1"`
+//	    Verbose bool `query:"verbose"`
+//	}
+//
+//	yar.Handle(router, "GET", "/users/:id", func(ctx context.Context, req GetUser) (User, error) {
+//	    user, ok := users[req.ID]
+//	    if !ok {
+//	        return User{}, yar.NewHTTPError(http.StatusNotFound, "user not found")
+//	    }
+//	    return user, nil
+//	})
+//
+// Req, which must be a struct, is filled with Bind and checked with Validate; failures are answered by the router's
+// ValidationErrorHandler. The response is encoded as JSON, or XML if the Accept header prefers it, with status 200 unless
+// Resp has a 'StatusCode() int' method; 204 and 304 responses have no body. Returned errors are answered by the router's
+// ErrorHandler (DefaultErrorHandler if not set). Both types are stored in the method's metadata and documented by
+// Router.OpenAPI.
+func Handle[Req, Resp any](r *Router, method, pattern string, fn func(ctx context.Context, req Req) (Resp, error)) *Route {
+	types := HandlerTypes{Request: reflect.TypeOf((*Req)(nil)).Elem(), Response: reflect.TypeOf((*Resp)(nil)).Elem()}
+	if types.Request.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("request type must be a struct, type=%s pattern=%s", types.Request, pattern))
+	}
+	if err := CheckValidateTags(types.Request); err != nil {
+		panic(fmt.Sprintf("%v, type=%s pattern=%s", err, types.Request, pattern))
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var in Req
+		err := Bind(req, &in)
+		if err == nil {
+			err = Validate(&in)
+		}
+		if err != nil {
+			r.handleError(w, req, err)
+			return
+		}
+		out, err := fn(req.Context(), in)
+		if err != nil {
+			r.handleError(w, req, err)
+			return
+		}
+		if err := writeResponse(w, req, out); err != nil {
+			r.handleError(w, req, err)
+		}
+	})
+	return r.AddHandler(method, pattern, handler).WithMethodMeta(method, HandlerTypesKey{}, types)
+}
+
+// Encodes the response in the negotiated format, nothing is written if encoding fails
+func writeResponse(w http.ResponseWriter, req *http.Request, resp interface{}) error {
+	status := http.StatusOK
+	if statusResp, ok := resp.(interface{ StatusCode() int }); ok {
+		status = statusResp.StatusCode()
+	}
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		w.WriteHeader(status)
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	contentType := negotiateResponseType(req.Header.Get("Accept"))
+	if contentType == "application/xml" {
+		buf.WriteString(xml.Header)
+		if err := xml.NewEncoder(buf).Encode(resp); err != nil {
+			return err
+		}
+	} else if err := json.NewEncoder(buf).Encode(resp); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	w.WriteHeader(status)
+	buf.WriteTo(w)
+	return nil
+}
+
+// Picks application/json or application/xml by the Accept header, JSON unless XML is preferred
+func negotiateResponseType(accept string) string {
+	q := parseAcceptEncoding(accept) // Same q-value syntax as Accept-Encoding
+	value := func(mediaType string) float64 {
+		for _, key := range []string{mediaType, "application/*", "*/*"} {
+			if v, ok := q[key]; ok {
+				return v
+			}
+		}
+		return 0
+	}
+	xmlQ := value("application/xml")
+	if textQ, ok := q["text/xml"]; ok && textQ > xmlQ {
+		xmlQ = textQ
+	}
+	if xmlQ > value("application/json") {
+		return "application/xml"
+	}
+	return "application/json"
+}
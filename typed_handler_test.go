@@ -0,0 +1,219 @@
+package yar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedUser struct {
+	ID   int    `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+}
+
+type typedCreated struct {
+	typedUser
+}
+
+func (typedCreated) StatusCode() int { return http.StatusCreated }
+
+type typedNoContent struct{}
+
+func (typedNoContent) StatusCode() int { return http.StatusNoContent }
+
+type typedGetUser struct {
+	ID      int    `path:"id" validate:"min=1"`
+	Verbose bool   `query:"verbose"`
+	Tenant  string `header:"X-Tenant" validate:"required"`
+}
+
+type typedCreateUser struct {
+	Tenant string `header:"X-Tenant"`
+	Name   string `json:"name" validate:"required,max=10"`
+	Role   string `json:"role" validate:"oneof=admin member"`
+}
+
+func TestHandle(t *testing.T) {
+	tcs := []struct {
+		method         string
+		path           string
+		body           string
+		header         map[string]string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"GET", "/users/7", "", map[string]string{"X-Tenant": "acme"}, 200, `{"id":7,"name":"acme-user"}` + "\n"},
+		{"GET", "/users/7", "", map[string]string{"X-Tenant": "acme", "Accept": "application/xml"}, 200, `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<typedUser><id>7</id><name>acme-user</name></typedUser>`},
+		{"GET", "/users/7", "", map[string]string{"X-Tenant": "acme", "Accept": "application/xml;q=0.5, application/json"}, 200, `{"id":7,"name":"acme-user"}` + "\n"},
+		{"GET", "/users/x", "", map[string]string{"X-Tenant": "acme"}, 400, `{"status":400,"error":"Bad Request","fields":[{"field":"ID","source":"path","name":"id","message":"invalid integer 'x'"}]}` + "\n"},
+		{"GET", "/users/0", "", nil, 422, `{"status":422,"error":"Unprocessable Entity","fields":[{"field":"ID","source":"path","name":"id","message":"must be at least 1"},{"field":"Tenant","source":"header","name":"X-Tenant","message":"is required"}]}` + "\n"},
+		{"GET", "/users/404", "", map[string]string{"X-Tenant": "acme"}, 404, `{"status":404,"error":"user not found"}` + "\n"},
+		{"GET", "/users/500", "", map[string]string{"X-Tenant": "acme"}, 500, `{"status":500,"error":"Internal Server Error"}` + "\n"},
+		{"GET", "/users/504", "", map[string]string{"X-Tenant": "acme"}, 504, `{"status":504,"error":"Gateway Timeout"}` + "\n"},
+		{"POST", "/users", `{"name": "Ann", "role": "admin"}`, nil, 201, `{"id":1,"name":"Ann"}` + "\n"},
+		{"DELETE", "/users/7", "", map[string]string{"X-Tenant": "acme"}, 204, ""},
+	}
+
+	router := NewRouter()
+	Handle(router, "GET", "/users/:id", func(ctx context.Context, req typedGetUser) (typedUser, error) {
+		switch req.ID {
+		case 404:
+			return typedUser{}, NewHTTPError(http.StatusNotFound, "user not found")
+		case 500:
+			return typedUser{}, errors.New("database is down")
+		case 504:
+			return typedUser{}, fmt.Errorf("querying: %w", context.DeadlineExceeded)
+		}
+		return typedUser{ID: req.ID, Name: req.Tenant + "-user"}, nil
+	})
+	Handle(router, "POST", "/users", func(ctx context.Context, req typedCreateUser) (typedCreated, error) {
+		return typedCreated{typedUser{ID: 1, Name: req.Name}}, nil
+	})
+	Handle(router, "DELETE", "/users/:id", func(ctx context.Context, req typedGetUser) (typedNoContent, error) {
+		return typedNoContent{}, nil
+	})
+
+	for _, tc := range tcs {
+		// Arrange
+		r, _ := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		if tc.body != "" {
+			r.Header.Set("Content-Type", "application/json")
+		}
+		for key, value := range tc.header {
+			r.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+
+		// Act
+		router.ServeHTTP(w, r)
+
+		// Assert
+		assert.Equal(t, tc.expectedStatus, w.Code, tc.path)
+		assert.Equal(t, tc.expectedBody, w.Body.String(), tc.path)
+	}
+}
+
+func TestHandleUsesRouterErrorHandlers(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	Handle(router, "GET", "/users/:id", func(ctx context.Context, req typedGetUser) (typedUser, error) {
+		return typedUser{}, errors.New("database is down")
+	})
+	var handled []error
+	router.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		handled = append(handled, err)
+		w.WriteHeader(http.StatusTeapot)
+	}
+	router.ValidationErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		handled = append(handled, err)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	rFailed, _ := http.NewRequest("GET", "/users/7", nil)
+	rFailed.Header.Set("X-Tenant", "acme")
+	rInvalid, _ := http.NewRequest("GET", "/users/7", nil)
+	failed, invalid := httptest.NewRecorder(), httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(failed, rFailed)
+	router.ServeHTTP(invalid, rInvalid)
+
+	// Assert
+	assert.Equal(t, http.StatusTeapot, failed.Code)
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+	assert.Equal(t, 2, len(handled))
+	assert.EqualError(t, handled[0], "database is down")
+	_, ok := handled[1].(*ValidationError)
+	assert.True(t, ok)
+}
+
+func TestHandleRequiresStructRequest(t *testing.T) {
+	assert.Panics(t, func() {
+		Handle(NewRouter(), "GET", "/", func(ctx context.Context, req string) (string, error) { return req, nil })
+	})
+}
+
+func TestHandleChecksValidateTags(t *testing.T) {
+	type request struct {
+		Code string `validate:"regex=("`
+	}
+
+	assert.Panics(t, func() {
+		Handle(NewRouter(), "GET", "/", func(ctx context.Context, req request) (string, error) { return req.Code, nil })
+	})
+}
+
+func TestHTTPError(t *testing.T) {
+	err := &HTTPError{Status: http.StatusConflict, Err: errors.New("duplicate key")}
+
+	assert.EqualError(t, err, "Conflict: duplicate key")
+	assert.Equal(t, http.StatusConflict, err.StatusCode())
+	assert.True(t, errors.Is(fmt.Errorf("creating user: %w", err), err.Err))
+}
+
+func TestHandleOpenAPI(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	Handle(router, "GET", "/users/:id", func(ctx context.Context, req typedGetUser) (typedUser, error) {
+		return typedUser{}, nil
+	})
+	Handle(router, "POST", "/users", func(ctx context.Context, req typedCreateUser) (typedCreated, error) {
+		return typedCreated{}, nil
+	})
+
+	// Act
+	doc := router.OpenAPI(OpenAPIInfo{Title: "Test", Version: "1.0"})
+
+	// Assert
+	get := doc.Paths["/users/{id}"].Get
+	assert.Equal(t, []OpenAPIParameter{
+		{Name: "id", In: "path", Required: true, Schema: Schema{"type": "integer", "minimum": 1.0}},
+		{Name: "verbose", In: "query", Schema: Schema{"type": "boolean"}},
+		{Name: "X-Tenant", In: "header", Required: true, Schema: Schema{"type": "string"}},
+	}, get.Parameters)
+	assert.Nil(t, get.RequestBody)
+	userSchema := Schema{"type": "object", "properties": map[string]Schema{"id": {"type": "integer"}, "name": {"type": "string"}}}
+	assert.Equal(t, userSchema, get.Responses["200"].Content["application/json"].Schema)
+	assert.Equal(t, "Error", get.Responses["default"].Description)
+
+	post := doc.Paths["/users"].Post
+	assert.Equal(t, []OpenAPIParameter{{Name: "X-Tenant", In: "header", Schema: Schema{"type": "string"}}}, post.Parameters)
+	assert.Equal(t, Schema{
+		"type": "object",
+		"properties": map[string]Schema{
+			"name": {"type": "string", "maxLength": 10.0},
+			"role": {"type": "string", "enum": []interface{}{"admin", "member"}},
+		},
+		"required": []string{"name"},
+	}, post.RequestBody.Content["application/json"].Schema)
+	assert.Equal(t, userSchema, post.Responses["200"].Content["application/json"].Schema) // Embedded fields are promoted
+}
+
+func TestSchemaFor(t *testing.T) {
+	type Node struct {
+		Value    float64           `json:"value" validate:"oneof=1 2.5"`
+		Children []*Node           `json:"children,omitempty" validate:"max=3"`
+		Labels   map[string]string `json:"labels"`
+		Data     []byte            `json:"data"`
+		Email    string            `json:"email" validate:"email"`
+		Code     string            `validate:"len=4,regex=^[a-z,]+$"`
+		Ignored  string            `json:"-"`
+		hidden   string
+	}
+
+	schema := SchemaFor(reflect.TypeOf(&Node{}))
+
+	data, _ := json.Marshal(schema)
+	assert.Equal(t, `{"properties":{"Code":{"maxLength":4,"minLength":4,"pattern":"^[a-z,]+$","type":"string"},`+
+		`"children":{"items":{"type":"object"},"maxItems":3,"type":"array"},"data":{"format":"byte","type":"string"},`+
+		`"email":{"format":"email","type":"string"},"labels":{"additionalProperties":{"type":"string"},"type":"object"},`+
+		`"value":{"enum":[1,2.5],"type":"number"}},"type":"object"}`, string(data))
+}
@@ -0,0 +1,359 @@
+package yar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ValidationError lists every field Validate found invalid
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + " " + f.Message
+	}
+	return "validation: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks a struct, or a pointer to one, against its fields' 'validate' tags:
+//
+//	type CreateUser struct {
+//	    Name  string   `json:"name" validate:"required,min=2,max=50"`
+//	    Email string   `json:"email" validate:"required,email"`
+//	    Role  string   `json:"role" validate:"oneof=admin member"`
+//	    Tags  []string `json:"tags" validate:"max=5"`
+//	    Code  string   `json:"code" validate:"omitempty,len=6,regex=^[A-Z0-9]+$"`
+//	}
+//
+// Rules are separated by commas:
+//   - required: not the zero value (nil, 0, "", empty slice or map)
+//   - omitempty: skip the other rules for the zero value
+//   - min=n, max=n, len=n: the value of numbers, the length in characters of strings, the length of slices and maps
+//   - oneof=a b c: one of the space separated values
+//   - regex=expr: strings matching the expression, it must be the last rule as it may contain commas
+//   - email: a plain email address, like 'ann@example.com'
+//
+// Nil pointers are only checked by required, other pointers are checked by what they point to. Nested structs and
+// slices of structs are validated too, with field paths like 'Items[2].Name'. Invalid tags are returned as a plain
+// error, see CheckValidateTags to find them up front.
+// Every invalid field is listed in the returned *ValidationError, with the source and name it is bound from.
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return errors.New("validation: value must be a struct or a pointer to one")
+	}
+	validationErr := &ValidationError{}
+	if err := validateStruct(rv, "", validationErr); err != nil {
+		return err
+	}
+	if len(validationErr.Fields) > 0 {
+		return validationErr
+	}
+	return nil
+}
+
+type validateField struct {
+	index  int
+	name   string // Empty for embedded structs, their fields are promoted
+	source string // Where Bind gets the field from, if it does
+	bindAs string
+	rules  []validateRule
+}
+
+type validateRule struct {
+	name    string
+	size    float64
+	options []string
+	regex   *regexp.Regexp
+}
+
+// The parsed fields of a struct type, or why its tags are invalid
+type validateFields struct {
+	fields []validateField
+	err    error
+}
+
+var validateFieldCache sync.Map // reflect.Type to validateFields
+
+func cachedValidateFields(t reflect.Type) ([]validateField, error) {
+	if cached, ok := validateFieldCache.Load(t); ok {
+		return cached.(validateFields).fields, cached.(validateFields).err
+	}
+	fields := []validateField{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() && !(sf.Anonymous && sf.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		rules, err := parseValidateTag(sf)
+		if err != nil {
+			validateFieldCache.Store(t, validateFields{err: err})
+			return nil, err
+		}
+		field := validateField{index: i, name: sf.Name, rules: rules}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			field.name = ""
+		}
+		for _, source := range []string{"path", "query", "header", "form", "json"} {
+			if name, _, _ := strings.Cut(sf.Tag.Get(source), ","); name != "" && name != "-" {
+				field.source, field.bindAs = source, name
+				break
+			}
+		}
+		fields = append(fields, field)
+	}
+	validateFieldCache.Store(t, validateFields{fields: fields})
+	return fields, nil
+}
+
+// CheckValidateTags returns an error for the first invalid 'validate' tag of a struct type, including the structs
+// nested in its fields, e.g. to fail at startup rather than on the first request. Handle calls it at registration.
+func CheckValidateTags(t reflect.Type) error {
+	return checkValidateTags(t, map[reflect.Type]bool{})
+}
+
+func checkValidateTags(t reflect.Type, checked map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || checked[t] {
+		return nil
+	}
+	checked[t] = true
+	fields, err := cachedValidateFields(t)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		if err := checkValidateTags(t.Field(field.index).Type, checked); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseValidateTag(sf reflect.StructField) ([]validateRule, error) {
+	tag := sf.Tag.Get("validate")
+	rules := []validateRule{}
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "regex=") { // Takes the rest of the tag
+			part, tag = tag, ""
+		} else {
+			part, tag, _ = strings.Cut(tag, ",")
+		}
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		rule := validateRule{name: name}
+		switch name {
+		case "required", "omitempty", "email":
+		case "min", "max", "len":
+			size, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid validate rule '%s' on field %s: expected a number", part, sf.Name)
+			}
+			rule.size = size
+		case "oneof":
+			rule.options = strings.Fields(arg)
+		case "regex":
+			regex, err := regexp.Compile(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid validate rule '%s' on field %s: %v", part, sf.Name, err)
+			}
+			rule.regex = regex
+		default:
+			return nil, fmt.Errorf("unknown validate rule '%s' on field %s", name, sf.Name)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func validateStruct(v reflect.Value, prefix string, validationErr *ValidationError) error {
+	fields, err := cachedValidateFields(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		fv := v.Field(field.index)
+		path := prefix + field.name
+		if field.name == "" {
+			path = strings.TrimSuffix(prefix, ".")
+		}
+		if msg := checkValidateRules(fv, field.rules); msg != "" {
+			validationErr.Fields = append(validationErr.Fields, FieldError{Field: path, Source: field.source, Name: field.bindAs, Message: msg})
+			continue
+		}
+		if err := validateNested(fv, path, validationErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validates structs within a field, directly, through pointers or in slices
+func validateNested(v reflect.Value, path string, validationErr *ValidationError) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.NumField() > 0 && v.Type().PkgPath() != "time" {
+			prefix := path
+			if prefix != "" {
+				prefix += "."
+			}
+			return validateStruct(v, prefix, validationErr)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateNested(v.Index(i), fmt.Sprintf("%s[%d]", path, i), validationErr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the message of the first failing rule, empty if all pass
+func checkValidateRules(v reflect.Value, rules []validateRule) string {
+	for _, rule := range rules {
+		if rule.name == "required" && v.IsZero() {
+			return "is required"
+		}
+		if rule.name == "omitempty" && v.IsZero() {
+			return ""
+		}
+	}
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	for _, rule := range rules {
+		switch rule.name {
+		case "min", "max", "len":
+			size, unit, ok := validateSize(v)
+			if !ok {
+				return fmt.Sprintf("cannot be checked with '%s', unsupported type %s", rule.name, v.Type())
+			}
+			bound := strconv.FormatFloat(rule.size, 'f', -1, 64)
+			switch {
+			case rule.name == "min" && size < rule.size:
+				return strings.TrimSpace(fmt.Sprintf("must be at least %s %s", bound, unit))
+			case rule.name == "max" && size > rule.size:
+				return strings.TrimSpace(fmt.Sprintf("must be at most %s %s", bound, unit))
+			case rule.name == "len" && size != rule.size:
+				return strings.TrimSpace(fmt.Sprintf("must be exactly %s %s", bound, unit))
+			}
+		case "oneof":
+			value := fmt.Sprint(v.Interface())
+			found := false
+			for _, option := range rule.options {
+				found = found || option == value
+			}
+			if !found {
+				return "must be one of: " + strings.Join(rule.options, ", ")
+			}
+		case "regex":
+			if v.Kind() != reflect.String || !rule.regex.MatchString(v.String()) {
+				return "must match " + rule.regex.String()
+			}
+		case "email":
+			address, err := mail.ParseAddress(v.String())
+			if v.Kind() != reflect.String || err != nil || address.Address != v.String() {
+				return "must be a valid email address"
+			}
+		}
+	}
+	return ""
+}
+
+// Returns what min, max and len compare, with its unit
+func validateSize(v reflect.Value) (float64, string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), "characters", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), "items", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "", true
+	}
+	return 0, "", false
+}
+
+// ErrorResponse is the JSON body written for requests that fail, e.g. by DefaultValidationErrorHandler
+type ErrorResponse struct {
+	Status int          `json:"status"`
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// WriteErrorResponse writes the error response as JSON, with its status code
+func WriteErrorResponse(w http.ResponseWriter, resp ErrorResponse) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(resp.Status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// DefaultValidationErrorHandler responds with an ErrorResponse listing the failing fields:
+// 400 Bad Request for a *BindError, 422 Unprocessable Entity for a *ValidationError.
+func DefaultValidationErrorHandler(w http.ResponseWriter, req *http.Request, err error) {
+	var bindErr *BindError
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &bindErr):
+		WriteErrorResponse(w, ErrorResponse{Status: http.StatusBadRequest, Error: http.StatusText(http.StatusBadRequest), Fields: bindErr.Fields})
+	case errors.As(err, &validationErr):
+		WriteErrorResponse(w, ErrorResponse{Status: http.StatusUnprocessableEntity, Error: http.StatusText(http.StatusUnprocessableEntity), Fields: validationErr.Fields})
+	default:
+		WriteErrorResponse(w, ErrorResponse{Status: http.StatusInternalServerError, Error: http.StatusText(http.StatusInternalServerError)})
+	}
+}
+
+// BindValid binds the request into dst and validates it. If either fails, the router's ValidationErrorHandler
+// (DefaultValidationErrorHandler if not set) responds and false is returned:
+//
+//	var req CreateUser
+//	if !yar.BindValid(w, r, &req) {
+//	    return
+//	}
+func BindValid(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	err := Bind(r, dst)
+	if err == nil {
+		err = Validate(dst)
+	}
+	if err == nil {
+		return true
+	}
+	var router *Router
+	if rc := getRouteContext(r); rc != nil {
+		router = rc.router
+	}
+	router.handleError(w, r, err)
+	return false
+}
@@ -0,0 +1,227 @@
+package yar
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type validateItem struct {
+	SKU      string `json:"sku" validate:"required,regex=^[A-Z]{3}-[0-9]+$"`
+	Quantity int    `json:"quantity" validate:"min=1,max=99"`
+}
+
+type validateRequest struct {
+	ID      int64          `path:"id" validate:"min=1"`
+	Name    string         `json:"name" validate:"required,min=2,max=5"`
+	Email   string         `json:"email" validate:"required,email"`
+	Role    string         `json:"role" validate:"oneof=admin member"`
+	Code    string         `json:"code" validate:"omitempty,len=4"`
+	Tags    []string       `json:"tags" validate:"max=2"`
+	Limit   *int           `query:"limit" validate:"max=100"`
+	Items   []validateItem `json:"items" validate:"required"`
+	Address struct {
+		City string `json:"city" validate:"required"`
+	} `json:"address"`
+}
+
+func validRequest() validateRequest {
+	req := validateRequest{ID: 1, Name: "Ann", Email: "ann@example.com", Role: "admin", Items: []validateItem{{SKU: "ABC-1", Quantity: 1}}}
+	req.Address.City = "Oslo"
+	return req
+}
+
+func TestValidate(t *testing.T) {
+	// Arrange
+	limit := 500
+	req := validRequest()
+	req.ID = 0
+	req.Name = "Annabelle"
+	req.Email = "Ann <ann@example.com>"
+	req.Role = "owner"
+	req.Code = "12345"
+	req.Tags = []string{"a", "b", "c"}
+	req.Limit = &limit
+	req.Items = append(req.Items, validateItem{SKU: "abc", Quantity: 100})
+	req.Address.City = ""
+
+	// Act
+	err := Validate(&req)
+
+	// Assert
+	validationErr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []FieldError{
+		{Field: "ID", Source: "path", Name: "id", Message: "must be at least 1"},
+		{Field: "Name", Source: "json", Name: "name", Message: "must be at most 5 characters"},
+		{Field: "Email", Source: "json", Name: "email", Message: "must be a valid email address"},
+		{Field: "Role", Source: "json", Name: "role", Message: "must be one of: admin, member"},
+		{Field: "Code", Source: "json", Name: "code", Message: "must be exactly 4 characters"},
+		{Field: "Tags", Source: "json", Name: "tags", Message: "must be at most 2 items"},
+		{Field: "Limit", Source: "query", Name: "limit", Message: "must be at most 100"},
+		{Field: "Items[1].SKU", Source: "json", Name: "sku", Message: "must match ^[A-Z]{3}-[0-9]+$"},
+		{Field: "Items[1].Quantity", Source: "json", Name: "quantity", Message: "must be at most 99"},
+		{Field: "Address.City", Source: "json", Name: "city", Message: "is required"},
+	}, validationErr.Fields)
+}
+
+func TestValidateValid(t *testing.T) {
+	req := validRequest()
+	limit := 100
+	req.Limit = &limit
+	req.Code = "ABCD"
+
+	assert.Nil(t, Validate(req))
+	assert.Nil(t, Validate(&req))
+}
+
+func TestValidateRequired(t *testing.T) {
+	// Arrange
+	req := validRequest()
+	req.Name, req.Email, req.Items = "", "", nil
+
+	// Act
+	err := Validate(&req)
+
+	// Assert
+	assert.EqualError(t, err, "validation: Name is required; Email is required; Items is required")
+}
+
+func TestValidateEmbeddedFieldsArePromoted(t *testing.T) {
+	type Page struct {
+		Page int `query:"page" validate:"min=1"`
+	}
+	type Request struct {
+		Page
+	}
+
+	err := Validate(Request{})
+
+	assert.Equal(t, []FieldError{{Field: "Page", Source: "query", Name: "page", Message: "must be at least 1"}}, err.(*ValidationError).Fields)
+}
+
+func TestValidateInvalidTags(t *testing.T) {
+	tcs := []struct {
+		value    interface{}
+		expected string
+	}{
+		{struct {
+			A int `validate:"positive"`
+		}{}, "unknown validate rule 'positive' on field A"},
+		{struct {
+			A int `validate:"min=one"`
+		}{}, "invalid validate rule 'min=one' on field A: expected a number"},
+		{struct {
+			A struct {
+				B string `validate:"regex=^[a-z+$"`
+			}
+		}{}, "invalid validate rule 'regex=^[a-z+$' on field B: error parsing regexp: missing closing ]: `[a-z+$`"},
+	}
+
+	for _, tc := range tcs {
+		// Act
+		err := Validate(tc.value)
+		checkErr := CheckValidateTags(reflect.TypeOf(tc.value))
+
+		// Assert
+		assert.EqualError(t, err, tc.expected)
+		assert.EqualError(t, checkErr, tc.expected)
+	}
+	assert.NotNil(t, Validate(5))
+}
+
+func TestBindValid(t *testing.T) {
+	tcs := []struct {
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   ErrorResponse
+	}{
+		{"/users/7", `{"name": "Ann", "email": "ann@example.com", "role": "member", "items": [{"sku": "ABC-1", "quantity": 1}], "address": {"city": "Oslo"}}`, 200, ErrorResponse{}},
+		{"/users/x", `{}`, 400, ErrorResponse{Status: 400, Error: "Bad Request", Fields: []FieldError{
+			{Field: "ID", Source: "path", Name: "id", Message: "invalid integer 'x'"},
+		}}},
+		{"/users/7", `{"name": "A", "email": "ann@example.com", "role": "member", "items": [{"sku": "ABC-1", "quantity": 1}], "address": {"city": "Oslo"}}`, 422, ErrorResponse{Status: 422, Error: "Unprocessable Entity", Fields: []FieldError{
+			{Field: "Name", Source: "json", Name: "name", Message: "must be at least 2 characters"},
+		}}},
+	}
+
+	for _, tc := range tcs {
+		// Arrange
+		router := NewRouter()
+		router.AddHandle("*", "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+			var req validateRequest
+			if !BindValid(w, r, &req) {
+				return
+			}
+			w.Write([]byte("ok"))
+		})
+		r, _ := http.NewRequest("POST", tc.path, strings.NewReader(tc.body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Act
+		router.ServeHTTP(w, r)
+
+		// Assert
+		assert.Equal(t, tc.expectedStatus, w.Code, tc.body)
+		if tc.expectedStatus != 200 {
+			var body ErrorResponse
+			assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tc.expectedBody, body, tc.body)
+		}
+	}
+}
+
+func TestBindValidUsesRouterErrorHandler(t *testing.T) {
+	// Arrange
+	var handledErr error
+	router := NewRouter()
+	router.AddHandle("*", "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		var req validateRequest
+		BindValid(w, r, &req)
+	})
+	router.ValidationErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		handledErr = err
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	r, _ := http.NewRequest("GET", "/users/7", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, 400, w.Code)
+	_, ok := handledErr.(*ValidationError)
+	assert.True(t, ok)
+}
+
+func TestBindValidOnStaticRouteUsesRouterErrorHandler(t *testing.T) {
+	// Arrange
+	router := NewRouter()
+	router.ValidationErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		w.WriteHeader(http.StatusTeapot)
+	}
+	router.Post("/static", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Name string `json:"name" validate:"required"`
+		}
+		BindValid(w, r, &req)
+	})
+	r, _ := http.NewRequest("POST", "/static", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, r)
+
+	// Assert
+	assert.Equal(t, http.StatusTeapot, w.Code)
+}
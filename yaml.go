@@ -0,0 +1,242 @@
+package yar
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// unmarshalYaml decodes a YAML document into v, like json.Unmarshal would decode its JSON equivalent.
+// Plain scalars are typed by the field they go to, so 'version: 1.0' sets a string field to "1.0".
+func unmarshalYaml(data []byte, v interface{}) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc.Kind == 0 { // Empty document
+		return nil
+	}
+	generic, err := yamlValue(&doc, reflect.TypeOf(v))
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// yamlValue converts the node to map[string]interface{}, []interface{} and scalar values, t being the type it
+// decodes into, nil if unknown
+func yamlValue(n *yaml.Node, t reflect.Type) (interface{}, error) {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface) {
+		if t.Kind() == reflect.Interface {
+			t = nil
+			break
+		}
+		t = t.Elem()
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		return yamlValue(n.Content[0], t)
+	case yaml.AliasNode:
+		return yamlValue(n.Alias, t)
+	case yaml.SequenceNode:
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		values := make([]interface{}, 0, len(n.Content))
+		for _, c := range n.Content {
+			v, err := yamlValue(c, elem)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	case yaml.MappingNode:
+		values := make(map[string]interface{}, len(n.Content)/2)
+		return values, yamlMapping(n, t, values)
+	}
+	if t != nil && n.Tag != "!!null" && (t.Kind() == reflect.String || reflect.PtrTo(t).Implements(textUnmarshalerType)) {
+		return n.Value, nil
+	}
+	var v interface{}
+	if err := n.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Adds the mapping's entries to values, the ones of '<<' merge keys first so that explicit keys win
+func yamlMapping(n *yaml.Node, t reflect.Type, values map[string]interface{}) error {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Tag != "!!merge" {
+			continue
+		}
+		merged := n.Content[i+1]
+		if merged.Kind == yaml.AliasNode {
+			merged = merged.Alias
+		}
+		sources := []*yaml.Node{merged}
+		if merged.Kind == yaml.SequenceNode {
+			sources = merged.Content
+		}
+		for _, source := range sources {
+			if source.Kind == yaml.AliasNode {
+				source = source.Alias
+			}
+			if source.Kind != yaml.MappingNode {
+				return fmt.Errorf("yaml: line %d: merge key value must be a mapping", source.Line)
+			}
+			if err := yamlMapping(source, t, values); err != nil {
+				return err
+			}
+		}
+	}
+	seen := make(map[string]bool, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key := n.Content[i]
+		if key.Tag == "!!merge" {
+			continue
+		}
+		if key.Kind != yaml.ScalarNode {
+			return fmt.Errorf("yaml: line %d: mapping keys must be scalars", key.Line)
+		}
+		if seen[key.Value] {
+			return fmt.Errorf("yaml: line %d: mapping key %q already defined", key.Line, key.Value)
+		}
+		seen[key.Value] = true
+		v, err := yamlValue(n.Content[i+1], yamlFieldType(t, key.Value))
+		if err != nil {
+			return err
+		}
+		values[key.Value] = v
+	}
+	return nil
+}
+
+// yamlFieldType returns the type the key of a t mapping decodes into, found like encoding/json finds struct fields
+func yamlFieldType(t reflect.Type, key string) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem()
+	case reflect.Struct:
+		if field, ok := jsonStructField(t, key); ok {
+			return field.Type
+		}
+	}
+	return nil
+}
+
+// writeYaml writes any JSON marshallable value as a block style YAML document
+func writeYaml(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	writeYamlValue(bw, generic, 0)
+	return bw.Flush()
+}
+
+func writeYamlValue(w *bufio.Writer, v interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			w.WriteString(pad + yamlScalar(k) + ":")
+			writeYamlChild(w, v[k], indent+1)
+		}
+	case []interface{}:
+		for _, e := range v {
+			w.WriteString(pad + "-")
+			writeYamlChild(w, e, indent+1)
+		}
+	default:
+		w.WriteString(pad + yamlScalar(v) + "\n")
+	}
+}
+
+func writeYamlChild(w *bufio.Writer, v interface{}, indent int) {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		if len(c) == 0 {
+			w.WriteString(" {}\n")
+			return
+		}
+	case []interface{}:
+		if len(c) == 0 {
+			w.WriteString(" []\n")
+			return
+		}
+	default:
+		w.WriteString(" " + yamlScalar(v) + "\n")
+		return
+	}
+	w.WriteString("\n")
+	writeYamlValue(w, v, indent)
+}
+
+func yamlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		if isPlainYamlString(v) {
+			return v
+		}
+		return strconv.Quote(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// isPlainYamlString reports whether s can be written unquoted without being read back as something else
+func isPlainYamlString(s string) bool {
+	if s == "" {
+		return false
+	}
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		isAlnum := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+		if !isAlnum && !strings.ContainsRune(" _./{}-", rune(c)) {
+			return false
+		}
+	}
+	return !strings.ContainsRune("{-. ", rune(s[0])) && s[len(s)-1] != ' '
+}
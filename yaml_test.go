@@ -0,0 +1,106 @@
+package yar
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalYaml(t *testing.T) {
+	// Arrange
+	type Item struct {
+		Name string `json:"name"`
+		Size int    `json:"size"`
+	}
+	type Document struct {
+		Version  string                 `json:"version"`
+		Enabled  string                 `json:"enabled"`
+		Escaped  string                 `json:"escaped"`
+		Missing  *string                `json:"missing"`
+		Count    int                    `json:"count"`
+		Items    []Item                 `json:"items"`
+		Labels   map[string]string      `json:"labels"`
+		Extra    map[string]interface{} `json:"extra"`
+		Untagged string
+	}
+	doc := `# Comment
+version: 1.0
+enabled: yes
+escaped: "tab\there \u00e9 \x41"
+missing: null
+count: 3
+defaults: &defaults
+  size: 2
+items:
+  - name: 007
+    <<: *defaults
+  - {name: second, size: 5}
+labels:
+  build: 42
+extra:
+  ratio: 0.5
+  on: true
+untagged: 3.10
+`
+	var v Document
+
+	// Act
+	err := unmarshalYaml([]byte(doc), &v)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, Document{
+		Version:  "1.0",
+		Enabled:  "yes",
+		Escaped:  "tab\there é A",
+		Count:    3,
+		Items:    []Item{{Name: "007", Size: 2}, {Name: "second", Size: 5}},
+		Labels:   map[string]string{"build": "42"},
+		Extra:    map[string]interface{}{"ratio": 0.5, "on": true},
+		Untagged: "3.10",
+	}, v)
+}
+
+func TestUnmarshalYamlErrors(t *testing.T) {
+	docs := []string{
+		"a: 1\n  b: 2\n",
+		"a: 1\na: 2\n",
+		"a: [1, 2\n",
+		"a: \"unterminated\n",
+		"a:\n\t- 1\n",
+		"- a\nb: 1\n",
+		"a: *unknown\n",
+		"a: [1]\n",
+	}
+
+	for _, doc := range docs {
+		var v struct {
+			A int `json:"a"`
+		}
+		err := unmarshalYaml([]byte(doc), &v)
+		assert.NotNil(t, err, doc)
+	}
+}
+
+func TestYamlRoundTrip(t *testing.T) {
+	// Arrange
+	v := map[string]interface{}{
+		"string":  "yes",
+		"number":  12.0,
+		"escaped": "a \"quoted\"\tvalue\n",
+		"list":    []interface{}{"a b", map[string]interface{}{"k": "-v"}},
+		"empty":   []interface{}{},
+	}
+	var out bytes.Buffer
+	var parsed map[string]interface{}
+
+	// Act
+	err1 := writeYaml(&out, v)
+	err2 := unmarshalYaml(out.Bytes(), &parsed)
+
+	// Assert
+	assert.Nil(t, err1)
+	assert.Nil(t, err2)
+	assert.Equal(t, v, parsed)
+}